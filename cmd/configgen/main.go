@@ -0,0 +1,207 @@
+// Command configgen writes the JSON Schema and an example configuration file
+// for a config struct, using config.JSONSchema and config.Sample.
+//
+// The struct must be declared in an importable (non-main) package. configgen
+// builds and runs a small program in a temporary directory inside that
+// package, so it works with internal packages too.
+//
+// Usage:
+//
+//	configgen -type AppConfig [-pkg ./internal/config] [-schema config.schema.json] [-sample config.example.yaml]
+//
+// or from a go:generate directive in the package declaring the struct:
+//
+//	//go:generate go run github.com/ArgonautPath/go-kit/cmd/configgen -type AppConfig -schema config.schema.json -sample config.example.yaml
+//
+// The sample format is chosen from the file extension (.yaml, .yml or
+// .json), or with -format. Use "-" to write to standard output.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// options are the command-line options of configgen.
+type options struct {
+	typeName string
+	pkg      string
+	schema   string
+	sample   string
+	format   string
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "configgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the arguments and generates the requested files.
+func run(args []string) error {
+	var opts options
+	fs := flag.NewFlagSet("configgen", flag.ContinueOnError)
+	fs.StringVar(&opts.typeName, "type", "", "name of the config struct type (required)")
+	fs.StringVar(&opts.pkg, "pkg", ".", "package declaring the type")
+	fs.StringVar(&opts.schema, "schema", "", "write the JSON Schema to this file")
+	fs.StringVar(&opts.sample, "sample", "", "write an example configuration file to this file")
+	fs.StringVar(&opts.format, "format", "", "sample format, yaml or json (default: from the -sample extension)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if opts.typeName == "" {
+		return fmt.Errorf("-type is required")
+	}
+	if opts.schema == "" && opts.sample == "" {
+		return fmt.Errorf("nothing to do: set -schema and/or -sample")
+	}
+	if opts.format == "" {
+		opts.format = "yaml"
+		if strings.EqualFold(filepath.Ext(opts.sample), ".json") {
+			opts.format = "json"
+		}
+	}
+
+	for _, path := range []*string{&opts.schema, &opts.sample} {
+		if *path == "" || *path == "-" {
+			continue
+		}
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return err
+		}
+		*path = abs
+	}
+
+	importPath, dir, err := resolvePackage(opts.pkg)
+	if err != nil {
+		return err
+	}
+	return generate(opts, importPath, dir)
+}
+
+// resolvePackage returns the import path and directory of a package.
+func resolvePackage(pkg string) (importPath, dir string, err error) {
+	out, err := exec.Command("go", "list", "-f", "{{.ImportPath}}\n{{.Dir}}\n{{.Name}}", pkg).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", "", fmt.Errorf("go list %s: %s", pkg, bytes.TrimSpace(exitErr.Stderr))
+		}
+		return "", "", fmt.Errorf("go list %s: %w", pkg, err)
+	}
+
+	fields := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(fields) != 3 {
+		return "", "", fmt.Errorf("go list %s: unexpected output %q", pkg, out)
+	}
+	if fields[2] == "main" {
+		return "", "", fmt.Errorf("package %s is a main package and cannot be imported; move the config struct to another package", pkg)
+	}
+	return fields[0], fields[1], nil
+}
+
+// generate writes the generator program into a temporary directory inside
+// the package directory and runs it.
+func generate(opts options, importPath, dir string) error {
+	source, err := generatorSource(opts, importPath)
+	if err != nil {
+		return err
+	}
+
+	// Directories starting with "_" are ignored by "./..." patterns.
+	tmpDir, err := os.MkdirTemp(dir, "_configgen")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), source, 0644); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "run", "./"+filepath.Base(tmpDir))
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run generator: %w", err)
+	}
+	return nil
+}
+
+// generatorTemplate is the program that calls config.JSONSchema and config.Sample.
+var generatorTemplate = template.Must(template.New("generator").Parse(`// Code generated by configgen. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ArgonautPath/go-kit/pkg/config"
+
+	target {{printf "%q" .ImportPath}}
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "configgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var v target.{{.TypeName}}
+{{if .Schema}}
+	schema, err := config.JSONSchema(v)
+	if err != nil {
+		return err
+	}
+	if err := write({{printf "%q" .Schema}}, append(schema, '\n')); err != nil {
+		return err
+	}
+{{end}}{{if .Sample}}
+	sample, err := config.Sample(v, config.Format({{printf "%q" .Format}}))
+	if err != nil {
+		return err
+	}
+	if err := write({{printf "%q" .Sample}}, sample); err != nil {
+		return err
+	}
+{{end}}
+	return nil
+}
+
+func write(path string, data []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+`))
+
+// generatorSource renders the generator program.
+func generatorSource(opts options, importPath string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := generatorTemplate.Execute(&buf, map[string]string{
+		"ImportPath": importPath,
+		"TypeName":   opts.typeName,
+		"Schema":     opts.schema,
+		"Sample":     opts.sample,
+		"Format":     opts.format,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "config.schema.json")
+	samplePath := filepath.Join(dir, "config.example.json")
+
+	err := run([]string{"-type", "AppConfig", "-pkg", "./testdata/appconfig", "-schema", schemaPath, "-sample", samplePath})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	var schema map[string]interface{}
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema["title"] != "AppConfig" {
+		t.Errorf("schema title = %v, want AppConfig", schema["title"])
+	}
+
+	sample, err := os.ReadFile(samplePath)
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+	if !strings.Contains(string(sample), `"port": 8080`) {
+		t.Errorf("sample = %s, want JSON with the default port", sample)
+	}
+
+	// The temporary generator is removed
+	entries, _ := os.ReadDir("testdata/appconfig")
+	if len(entries) != 1 {
+		t.Errorf("testdata/appconfig has %d entries, want the generator to be removed", len(entries))
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"missing type", []string{"-schema", "-"}, "-type is required"},
+		{"no output", []string{"-type", "AppConfig"}, "nothing to do"},
+		{"main package", []string{"-type", "options", "-pkg", ".", "-schema", "-"}, "main package"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+// Package appconfig declares a config struct for the configgen tests.
+package appconfig
+
+// AppConfig is the configuration of a test service.
+type AppConfig struct {
+	Name     string `config:"required,desc='Service name'"`
+	Port     int    `config:"default=8080,validate=range=1,65535"`
+	Database struct {
+		Host string `config:"default=localhost"`
+	}
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Warning reports the use of a deprecated configuration key: an old name
+// given with the alias= tag option, or a field marked deprecated. Loaders
+// pass warnings to Config.OnWarning.
+type Warning struct {
+	// Field is the dotted path of the field, e.g. "Database.Host".
+	Field string
+	// Key is the name that was used: an environment variable, a file key
+	// such as "db_host", or a file path for DirSource.
+	Key string
+	// Origin is the source the key was read from.
+	Origin Origin
+	// Message describes the deprecation, e.g.
+	// `key "db_host" is deprecated, use "database.host"`.
+	Message string
+}
+
+// String formats the warning with its origin, e.g.
+// `file:config.yaml: key "db_host" is deprecated, use "database.host"`.
+func (w Warning) String() string {
+	if w.Origin.Source == "" {
+		return w.Message
+	}
+	return w.Origin.String() + ": " + w.Message
+}
+
+// aliasedField is a field with alias= or deprecated= tag options.
+type aliasedField struct {
+	// index leads from the root struct to the field.
+	index []int
+	// path is the dotted field path and key the dotted file key, e.g.
+	// "Database.Host" and "database.host".
+	path string
+	key  string
+	// aliases are the old names of the field, as dotted file keys from the
+	// root of the document, e.g. "db_host".
+	aliases []string
+	// deprecated marks the field itself as deprecated, with an optional
+	// message.
+	deprecated bool
+	message    string
+}
+
+// splitAliases returns the names listed in an alias= option, separated by "|".
+func splitAliases(option string) []string {
+	var aliases []string
+	for _, alias := range strings.Split(option, "|") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// deprecationMessage formats the use of a deprecated name: "X is deprecated"
+// followed by the deprecated= message, if any.
+func deprecationMessage(name, message string) string {
+	if message == "" {
+		return name + " is deprecated"
+	}
+	return name + " is deprecated: " + message
+}
+
+// aliasedFields lists the fields of struct type t, nested ones included,
+// that have alias= or deprecated= options.
+func aliasedFields(t reflect.Type) []aliasedField {
+	var fields []aliasedField
+	collectAliasedFields(t, nil, "", "", map[reflect.Type]bool{}, &fields)
+	return fields
+}
+
+// collectAliasedFields appends the aliased fields of struct type t. seen
+// stops at recursive types.
+func collectAliasedFields(t reflect.Type, index []int, path, key string, seen map[reflect.Type]bool, fields *[]aliasedField) {
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := fileKey(field)
+		if !field.IsExported() || name == "" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		fieldPath := joinPath(path, field.Name)
+		fieldKey := joinKey(key, name)
+
+		switch {
+		case isNestedStruct(field.Type):
+			collectAliasedFields(field.Type, fieldIndex, fieldPath, fieldKey, seen, fields)
+			continue
+		case field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem()):
+			if !seen[field.Type.Elem()] {
+				collectAliasedFields(field.Type.Elem(), fieldIndex, fieldPath, fieldKey, seen, fields)
+			}
+			continue
+		}
+
+		options := parseTagOptions(field.Tag.Get("config"))
+		message, deprecated := options["deprecated"]
+		aliases := splitAliases(options["alias"])
+		if len(aliases) == 0 && !deprecated {
+			continue
+		}
+		*fields = append(*fields, aliasedField{
+			index:      fieldIndex,
+			path:       fieldPath,
+			key:        fieldKey,
+			aliases:    aliases,
+			deprecated: deprecated,
+			message:    message,
+		})
+	}
+}
+
+// aliasKeys returns the lower-cased file keys of the aliases of type t, which
+// strict decoding accepts although they match no field. The keys of aliases
+// map to true and their parent keys, e.g. "legacy" for "legacy.host", to
+// false: strict decoding checks the keys below a parent.
+func aliasKeys(t reflect.Type) map[string]bool {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	for _, field := range aliasedFields(t) {
+		for _, alias := range field.aliases {
+			segments := strings.Split(strings.ToLower(alias), ".")
+			for i := range segments {
+				key := strings.Join(segments[:i+1], ".")
+				keys[key] = keys[key] || i == len(segments)-1
+			}
+		}
+	}
+	return keys
+}
+
+// unknownAliasKey reports whether the unknown key at path is an alias, or the
+// parent of one. For a parent, keyType returns the type to check the keys
+// below it against: a struct without fields, so that only aliases pass.
+func unknownAliasKey(aliases map[string]bool, path string) (keyType reflect.Type, ok bool) {
+	alias, ok := aliases[strings.ToLower(path)]
+	switch {
+	case !ok:
+		return nil, false
+	case alias:
+		return interfaceType, true
+	default:
+		return emptyStructType, true
+	}
+}
+
+// emptyStructType is the type of struct{}.
+var emptyStructType = reflect.TypeOf(struct{}{})
+
+// applyFileAliases sets the aliased fields whose old names appear in a
+// decoded document and reports each deprecated key used through warn. previous is
+// a copy of the struct taken before data was decoded into rv, used to tell
+// which fields the document set. It fails if an old and a new name are both
+// set with different values.
+func applyFileAliases(fields []aliasedField, format Format, data []byte, rv, previous reflect.Value, warn func(Warning)) error {
+	tree, err := decodeTree(format, data)
+	if err != nil {
+		// Old names are only looked up in formats that decode into a map.
+		return nil
+	}
+
+	var errs DecodeErrors
+	for _, f := range fields {
+		// The document sets the field under its new name if the key is
+		// there or, for names given by struct tags, if decoding changed it.
+		_, present := lookupTree(tree, f.key)
+		if current, ok := fieldByIndexNoAlloc(rv, f.index); ok && !present {
+			if old, ok := fieldByIndexNoAlloc(previous, f.index); ok {
+				present = !reflect.DeepEqual(current.Interface(), old.Interface())
+			} else {
+				present = !current.IsZero()
+			}
+		}
+		setBy := f.key
+		if present && f.deprecated {
+			warn(Warning{Field: f.path, Key: f.key, Message: deprecationMessage(fmt.Sprintf("key %q", f.key), f.message)})
+		}
+
+		for _, alias := range f.aliases {
+			value, ok := lookupTree(tree, alias)
+			if !ok {
+				continue
+			}
+			warn(Warning{Field: f.path, Key: alias, Message: fmt.Sprintf("key %q is deprecated, use %q", alias, f.key)})
+
+			field := fieldByIndexAlloc(rv, f.index)
+			converted := reflect.New(field.Type()).Elem()
+			if err := setTreeValue(converted, value); err != nil {
+				errs = append(errs, &DecodeError{Message: fmt.Sprintf("key %q: %v", alias, err)})
+				continue
+			}
+			if present {
+				if !reflect.DeepEqual(converted.Interface(), field.Interface()) {
+					errs = append(errs, &DecodeError{Message: fmt.Sprintf("keys %q and %q are both set with different values", alias, setBy)})
+				}
+				continue
+			}
+			field.Set(converted)
+			present, setBy = true, alias
+		}
+	}
+	return errs.errorOrNil()
+}
+
+// fieldByIndexNoAlloc returns the nested field at index, or false if a nil
+// pointer lies on the way.
+func fieldByIndexNoAlloc(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, fieldIndex := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(fieldIndex)
+	}
+	return rv, true
+}
+
+// decodeTree decodes a document into nested maps. Flat formats yield string
+// values, split into nested maps at the dots of their keys.
+func decodeTree(format Format, data []byte) (map[string]interface{}, error) {
+	var entries []flatEntry
+	var err error
+	switch format {
+	case INIFormat:
+		entries, err = parseINI(bytes.NewReader(data))
+	case PropertiesFormat:
+		entries, err = parseProperties(bytes.NewReader(data))
+	default:
+		decoder, err := NewDecoder(format)
+		if err != nil {
+			return nil, err
+		}
+		var tree map[string]interface{}
+		if err := decoder.Decode(bytes.NewReader(data), &tree); err != nil {
+			return nil, err
+		}
+		return tree, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]interface{})
+	for _, entry := range entries {
+		node := tree
+		segments := strings.Split(entry.key, ".")
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := node[segment].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				node[segment] = child
+			}
+			node = child
+		}
+		node[segments[len(segments)-1]] = entry.value
+	}
+	return tree, nil
+}
+
+// lookupTree returns the value at a dotted key, matching each segment
+// without regard to case.
+func lookupTree(tree map[string]interface{}, key string) (interface{}, bool) {
+	var value interface{} = tree
+	for _, segment := range strings.Split(key, ".") {
+		node, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = node[segment]
+		if !ok {
+			for name, v := range node {
+				if strings.EqualFold(name, segment) {
+					value, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setTreeValue stores a value of a decoded document in rv. Strings use the
+// same conversion as environment variables; other values are converted by
+// yaml.v3, which accepts the values of every tree format.
+func setTreeValue(rv reflect.Value, value interface{}) error {
+	if s, ok := value.(string); ok {
+		return setFieldValue(rv, s)
+	}
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+	return node.Decode(rv.Addr().Interface())
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type aliasConfig struct {
+	Database struct {
+		Host string `config:"alias=db_host"`
+		Port int    `config:"alias=db_port|database.portnumber"`
+	}
+	Debug bool `config:"deprecated=use log.level"`
+}
+
+func TestEnvSource_Aliases(t *testing.T) {
+	os.Setenv("APP_DB_HOST", "old.local")
+	os.Setenv("APP_DATABASE_PORT", "5432")
+	os.Setenv("APP_DEBUG", "true")
+	defer os.Unsetenv("APP_DB_HOST")
+	defer os.Unsetenv("APP_DATABASE_PORT")
+	defer os.Unsetenv("APP_DEBUG")
+
+	var cfg aliasConfig
+	source := NewEnvSource("APP")
+	report, err := source.loadReport(context.Background(), &cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Host != "old.local" || cfg.Database.Port != 5432 || !cfg.Debug {
+		t.Errorf("Load() = %+v", cfg)
+	}
+	if key := report.keys["Database.Host"]; key != "APP_DB_HOST" {
+		t.Errorf("key of Database.Host = %q, want APP_DB_HOST", key)
+	}
+
+	want := []Warning{
+		{Field: "Database.Host", Key: "APP_DB_HOST", Message: "APP_DB_HOST is deprecated, use APP_DATABASE_HOST"},
+		{Field: "Debug", Key: "APP_DEBUG", Message: "APP_DEBUG is deprecated: use log.level"},
+	}
+	if !reflect.DeepEqual(report.warns, want) {
+		t.Errorf("warnings = %+v, want %+v", report.warns, want)
+	}
+
+	// The same value under both names is accepted
+	os.Setenv("APP_DATABASE_HOST", "old.local")
+	defer os.Unsetenv("APP_DATABASE_HOST")
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	os.Setenv("APP_DATABASE_HOST", "new.local")
+	err = source.Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "both APP_DATABASE_HOST and APP_DB_HOST are set with different values") {
+		t.Errorf("Load() error = %v, want conflict", err)
+	}
+}
+
+func TestDecodeFile_Aliases(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+	}{
+		{"yaml", YAMLFormat, "db_host: old.local\ndatabase:\n  portnumber: 5432\n"},
+		{"json", JSONFormat, `{"db_host": "old.local", "database": {"portnumber": 5432}}`},
+		{"toml", TOMLFormat, "db_host = \"old.local\"\n\n[database]\nportnumber = 5432\n"},
+		{"ini", INIFormat, "db_host = old.local\n\n[database]\nportnumber = 5432\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg aliasConfig
+			var warnings []string
+			warn := func(w Warning) { warnings = append(warnings, w.Message) }
+			if err := decodeFormat("config", tt.format, []byte(tt.data), &cfg, true, warn, nil); err != nil {
+				t.Fatalf("decode error = %v", err)
+			}
+			if cfg.Database.Host != "old.local" || cfg.Database.Port != 5432 {
+				t.Errorf("decoded %+v", cfg)
+			}
+			want := []string{
+				`key "db_host" is deprecated, use "database.host"`,
+				`key "database.portnumber" is deprecated, use "database.port"`,
+			}
+			if !reflect.DeepEqual(warnings, want) {
+				t.Errorf("warnings = %q, want %q", warnings, want)
+			}
+		})
+	}
+}
+
+func TestDecodeFile_AliasErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		data    string
+		wantErr string
+	}{
+		{"conflict", YAMLFormat, "db_host: old.local\ndatabase:\n  host: new.local\n",
+			`config.yaml: keys "db_host" and "database.host" are both set with different values`},
+		{"two aliases", JSONFormat, `{"db_port": 5432, "database": {"portnumber": 5433}}`,
+			`config.yaml: keys "database.portnumber" and "db_port" are both set with different values`},
+		{"bad value", YAMLFormat, "db_port: many\n",
+			`config.yaml: key "db_port": `},
+		{"unknown key", YAMLFormat, "db_hots: old.local\n",
+			`config.yaml:1:1: unknown key "db_hots"`},
+		{"unknown nested key", TOMLFormat, "[database]\nportnumbr = 5432\n",
+			`config.yaml:2:1: unknown key "database.portnumbr"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg aliasConfig
+			err := decodeFormat("config.yaml", tt.format, []byte(tt.data), &cfg, true, func(Warning) {}, nil)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("decode error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The same value under both names is accepted
+	var cfg aliasConfig
+	if err := decodeFormat("config.yaml", YAMLFormat, []byte("db_host: a\ndatabase:\n  host: a\n"), &cfg, true, func(Warning) {}, nil); err != nil {
+		t.Errorf("decode error = %v", err)
+	}
+}
+
+func TestLoader_Warnings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("db_host: old.local\ndebug: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	var cfg aliasConfig
+	l := NewLoader(WithFile(path), WithStrict(), WithWarningHandler(func(w Warning) {
+		warnings = append(warnings, w.Field+" "+w.String())
+	}))
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Host != "old.local" {
+		t.Errorf("Database.Host = %q, want old.local", cfg.Database.Host)
+	}
+
+	want := []string{
+		`Database.Host file:` + path + `: key "db_host" is deprecated, use "database.host"`,
+		`Debug file:` + path + `: key "debug" is deprecated: use log.level`,
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+	if origin := l.(ProvenanceReporter).Provenance()["Database.Host"]; origin.Source != "file" {
+		t.Errorf("origin of Database.Host = %v, want file", origin)
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// condition describes a cross-field tag option such as required_if or excluded_with.
+type condition struct {
+	// name is the tag option, e.g. "required_if".
+	name string
+	// required is true for required_* options and false for excluded_* options.
+	required bool
+	// kind selects how the option argument is evaluated:
+	//   "if"      - "Field value": applies when Field equals value
+	//   "unless"  - "Field value": applies when Field does not equal value
+	//   "with"    - "Field [Field...]": applies when any listed field is set
+	//   "without" - "Field [Field...]": applies when any listed field is not set
+	kind string
+}
+
+// conditions lists the cross-field options in the order they are checked.
+var conditions = []condition{
+	{name: "required_if", required: true, kind: "if"},
+	{name: "required_unless", required: true, kind: "unless"},
+	{name: "required_with", required: true, kind: "with"},
+	{name: "required_without", required: true, kind: "without"},
+	{name: "excluded_if", required: false, kind: "if"},
+	{name: "excluded_unless", required: false, kind: "unless"},
+	{name: "excluded_with", required: false, kind: "with"},
+	{name: "excluded_without", required: false, kind: "without"},
+}
+
+// validateConditions applies the cross-field options of a field.
+//
+// Field paths in option arguments are resolved relative to the struct that
+// contains the field ("Enabled", "TLS.Enabled"), or from the top-level struct
+// when prefixed with "$." ("$.Server.TLS.Enabled").
+//
+// Examples:
+//
+//	CertFile     string `config:"required_if=Enabled true"`
+//	Password     string `config:"required_without=PasswordFile,excluded_with=PasswordFile"`
+//	PasswordFile string
+func (v *structValidator) validateConditions(path string, fieldValue, parent reflect.Value, options map[string]string) *ValidationError {
+	for _, cond := range conditions {
+		arg, ok := options[cond.name]
+		if !ok {
+			continue
+		}
+
+		reason, applies, err := v.evaluateCondition(cond, arg, parent)
+		if err != nil {
+			return &ValidationError{
+				Field:   path,
+				Value:   fieldValue.Interface(),
+				Message: fmt.Sprintf("%s: %v", cond.name, err),
+			}
+		}
+		if !applies {
+			continue
+		}
+
+		if cond.required {
+			if NewRequiredValidator().Validate(fieldValue.Interface()) != nil {
+				return &ValidationError{
+					Field:   path,
+					Value:   fieldValue.Interface(),
+					Message: "field is required " + reason,
+				}
+			}
+		} else if !isZeroValue(fieldValue) {
+			return &ValidationError{
+				Field:   path,
+				Value:   fieldValue.Interface(),
+				Message: "field must be empty " + reason,
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateCondition reports whether a condition applies and, if so, a short
+// explanation such as "when TLS.Enabled is true".
+func (v *structValidator) evaluateCondition(cond condition, arg string, parent reflect.Value) (reason string, applies bool, err error) {
+	switch cond.kind {
+	case "if", "unless":
+		fieldPath, expected, found := strings.Cut(strings.TrimSpace(arg), " ")
+		if !found || fieldPath == "" {
+			return "", false, fmt.Errorf("expected \"Field value\", got %q", arg)
+		}
+		expected = strings.TrimSpace(expected)
+
+		other, err := v.resolveField(parent, fieldPath)
+		if err != nil {
+			return "", false, err
+		}
+
+		equal := valueEquals(other, expected)
+		if cond.kind == "if" {
+			return fmt.Sprintf("when %s is %s", fieldPath, expected), equal, nil
+		}
+		return fmt.Sprintf("unless %s is %s", fieldPath, expected), !equal, nil
+
+	default: // "with", "without"
+		fieldPaths := strings.Fields(arg)
+		if len(fieldPaths) == 0 {
+			return "", false, fmt.Errorf("expected at least one field")
+		}
+
+		for _, fieldPath := range fieldPaths {
+			other, err := v.resolveField(parent, fieldPath)
+			if err != nil {
+				return "", false, err
+			}
+
+			set := other.IsValid() && !isZeroValue(other)
+			if cond.kind == "with" && set {
+				return fmt.Sprintf("when %s is set", fieldPath), true, nil
+			}
+			if cond.kind == "without" && !set {
+				return fmt.Sprintf("when %s is not set", fieldPath), true, nil
+			}
+		}
+		return "", false, nil
+	}
+}
+
+// resolveField finds a field by dotted path, relative to parent or, with a
+// "$." prefix, to the top-level struct. Nil pointers along the path, including
+// nil embedded pointers holding a promoted field, yield an invalid Value, which
+// is treated as an unset field.
+func (v *structValidator) resolveField(parent reflect.Value, fieldPath string) (reflect.Value, error) {
+	current := parent
+	relative := fieldPath
+	if strings.HasPrefix(fieldPath, "$.") {
+		current = v.root
+		relative = strings.TrimPrefix(fieldPath, "$.")
+	}
+
+	for _, name := range strings.Split(relative, ".") {
+		for current.Kind() == reflect.Ptr {
+			if current.IsNil() {
+				return reflect.Value{}, nil
+			}
+			current = current.Elem()
+		}
+		if current.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("cannot resolve %q: %s is not a struct", fieldPath, current.Type())
+		}
+
+		field, ok := current.Type().FieldByName(name)
+		if !ok || !field.IsExported() {
+			return reflect.Value{}, fmt.Errorf("unknown field %q", fieldPath)
+		}
+		// A nil embedded pointer on the way to a promoted field leaves it unset
+		next, err := current.FieldByIndexErr(field.Index)
+		if err != nil {
+			return reflect.Value{}, nil
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// valueEquals compares a field with the string form of a value, dereferencing pointers.
+func valueEquals(v reflect.Value, expected string) bool {
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return false
+	}
+	return fmt.Sprint(v.Interface()) == expected
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateStruct_Conditions(t *testing.T) {
+	type TLSConfig struct {
+		Enabled  bool
+		CertFile string `config:"required_if=Enabled true"`
+		KeyFile  string `config:"required_with=CertFile"`
+		Mode     string `config:"excluded_unless=$.Env production"`
+	}
+
+	type AppConfig struct {
+		Env          string
+		Password     string `config:"required_without=PasswordFile,excluded_with=PasswordFile"`
+		PasswordFile string
+		Debug        bool `config:"excluded_if=Env production"`
+		TLS          TLSConfig
+		Audit        string `config:"required_unless=Env dev"`
+	}
+
+	tests := []struct {
+		name       string
+		cfg        AppConfig
+		wantFields []string
+	}{
+		{
+			name: "all conditions satisfied",
+			cfg: AppConfig{
+				Env:      "production",
+				Password: "secret",
+				TLS:      TLSConfig{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", Mode: "strict"},
+				Audit:    "on",
+			},
+		},
+		{
+			name: "conditions not triggered",
+			cfg: AppConfig{
+				Env:          "dev",
+				PasswordFile: "/run/secrets/db",
+			},
+		},
+		{
+			name: "cert required when TLS enabled",
+			cfg: AppConfig{
+				Env:      "dev",
+				Password: "secret",
+				TLS:      TLSConfig{Enabled: true},
+			},
+			wantFields: []string{"TLS.CertFile"},
+		},
+		{
+			name: "key required with cert",
+			cfg: AppConfig{
+				Env:      "dev",
+				Password: "secret",
+				TLS:      TLSConfig{CertFile: "cert.pem"},
+			},
+			wantFields: []string{"TLS.KeyFile"},
+		},
+		{
+			name: "neither password nor password file",
+			cfg: AppConfig{
+				Env: "dev",
+			},
+			wantFields: []string{"Password"},
+		},
+		{
+			name: "both password and password file",
+			cfg: AppConfig{
+				Env:          "dev",
+				Password:     "secret",
+				PasswordFile: "/run/secrets/db",
+			},
+			wantFields: []string{"Password"},
+		},
+		{
+			name: "excluded fields and absolute path",
+			cfg: AppConfig{
+				Env:      "staging",
+				Password: "secret",
+				Debug:    true,
+				TLS:      TLSConfig{Mode: "strict"},
+				Audit:    "on",
+			},
+			wantFields: []string{"TLS.Mode"},
+		},
+		{
+			name: "debug excluded in production",
+			cfg: AppConfig{
+				Env:      "production",
+				Password: "secret",
+				Debug:    true,
+				Audit:    "on",
+			},
+			wantFields: []string{"Debug"},
+		},
+		{
+			name: "audit required unless dev",
+			cfg: AppConfig{
+				Env:      "staging",
+				Password: "secret",
+			},
+			wantFields: []string{"Audit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(tt.cfg)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Errorf("ValidateStruct() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateStruct() error = %v, want ValidationErrors", err)
+			}
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("failing fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidateStruct_ConditionMessage(t *testing.T) {
+	type TLSConfig struct {
+		Enabled  bool
+		CertFile string `config:"required_if=Enabled true"`
+	}
+
+	err := ValidateStruct(struct{ TLS TLSConfig }{TLS: TLSConfig{Enabled: true}})
+	if err == nil || !strings.Contains(err.Error(), "field is required when Enabled is true") {
+		t.Errorf("ValidateStruct() error = %v, want message naming the condition", err)
+	}
+}
+
+func TestValidateStruct_ConditionUnknownField(t *testing.T) {
+	type BrokenConfig struct {
+		Value string `config:"required_with=Missing"`
+	}
+
+	err := ValidateStruct(BrokenConfig{})
+	if err == nil || !strings.Contains(err.Error(), `unknown field "Missing"`) {
+		t.Errorf("ValidateStruct() error = %v, want unknown field error", err)
+	}
+}
+
+func TestValidateStruct_ConditionNilEmbeddedPointer(t *testing.T) {
+	type Shared struct {
+		Region string
+	}
+	type AppConfig struct {
+		*Shared
+		Bucket string `config:"required_with=Region"`
+		Zone   string `config:"required_if=Region eu"`
+	}
+
+	if err := ValidateStruct(AppConfig{}); err != nil {
+		t.Errorf("ValidateStruct() error = %v, want nil for nil embedded pointer", err)
+	}
+
+	err := ValidateStruct(AppConfig{Shared: &Shared{Region: "eu"}})
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("ValidateStruct() error = %v, want Bucket and Zone required", err)
+	}
+}
+
+// hookedDatabase validates its own fields with a pointer receiver.
+type hookedDatabase struct {
+	MinConns int
+	MaxConns int `config:"validate=range=1,"`
+}
+
+func (d *hookedDatabase) Validate() error {
+	if d.MinConns > d.MaxConns {
+		return &ValidationError{Field: "MinConns", Value: d.MinConns, Message: "must not exceed MaxConns"}
+	}
+	return nil
+}
+
+// hookedConfig validates itself with a value receiver.
+type hookedConfig struct {
+	Name     string `config:"required"`
+	Database hookedDatabase
+}
+
+func (c hookedConfig) Validate() error {
+	if c.Name == "forbidden" {
+		return fmt.Errorf("name %q is reserved", c.Name)
+	}
+	return nil
+}
+
+func TestValidateStruct_ValidateHook(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       interface{}
+		wantField string
+		wantErr   bool
+	}{
+		{
+			name: "valid",
+			cfg:  hookedConfig{Name: "app", Database: hookedDatabase{MinConns: 1, MaxConns: 5}},
+		},
+		{
+			name:      "nested hook with pointer receiver",
+			cfg:       hookedConfig{Name: "app", Database: hookedDatabase{MinConns: 10, MaxConns: 5}},
+			wantField: "Database.MinConns",
+			wantErr:   true,
+		},
+		{
+			name:      "top-level hook with plain error",
+			cfg:       &hookedConfig{Name: "forbidden", Database: hookedDatabase{MaxConns: 5}},
+			wantField: "hookedConfig",
+			wantErr:   true,
+		},
+		{
+			name:      "hooks skipped when tag checks fail",
+			cfg:       hookedConfig{Name: "forbidden", Database: hookedDatabase{MinConns: 10}},
+			wantField: "Database.MaxConns",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateStruct() error = %v, want ValidationErrors", err)
+			}
+			if len(errs) != 1 || errs[0].Field != tt.wantField {
+				t.Errorf("ValidateStruct() error = %v, want single error for %q", err, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+)
+
+// setFieldValue converts a string into the type of fieldValue and stores it.
+// It is shared by every source that reads plain strings (environment
+// variables, default= tags) so that all of them accept the same syntax:
+//   - encoding.TextUnmarshaler implementations (net.IP, time.Time, ...) and url.URL
+//   - time.Duration in time.ParseDuration syntax ("30s", "1h30m")
+//   - pointers, which are allocated when nil
+//   - slices and arrays as lists: "a,b,c" or "a;b;c" (semicolons take
+//     precedence, so "a,b;c" yields "a,b" and "c")
+//   - maps as "key=value" lists: "k1=v1,k2=v2" or "k1=v1;k2=v2"
+//   - strings, booleans, integers, unsigned integers and floats
+func setFieldValue(fieldValue reflect.Value, value string) error {
+	if !fieldValue.CanSet() {
+		return fmt.Errorf("field cannot be set")
+	}
+
+	if fieldValue.Kind() == reflect.Ptr {
+		ptr := reflect.New(fieldValue.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		fieldValue.Set(ptr)
+		return nil
+	}
+
+	if isTextUnmarshaler(fieldValue.Type()) {
+		unmarshaler := fieldValue.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := unmarshaler.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid %s: %w", fieldValue.Type(), err)
+		}
+		return nil
+	}
+
+	switch fieldValue.Type() {
+	case urlType:
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid URL: %w", err)
+		}
+		fieldValue.Set(reflect.ValueOf(*parsed))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		fieldValue.SetInt(int64(d))
+		return nil
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.String:
+		fieldValue.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(value, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer: %w", err)
+		}
+		fieldValue.SetInt(intVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(value, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer: %w", err)
+		}
+		fieldValue.SetUint(uintVal)
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(value, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid float: %w", err)
+		}
+		fieldValue.SetFloat(floatVal)
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean: %w", err)
+		}
+		fieldValue.SetBool(boolVal)
+	case reflect.Slice:
+		return setSliceValue(fieldValue, value)
+	case reflect.Array:
+		return setArrayValue(fieldValue, value)
+	case reflect.Map:
+		return setMapValue(fieldValue, value)
+	case reflect.Interface:
+		if fieldValue.NumMethod() != 0 {
+			return fmt.Errorf("unsupported field type: %s", fieldValue.Type())
+		}
+		fieldValue.Set(reflect.ValueOf(value))
+	default:
+		return fmt.Errorf("unsupported field type: %s", fieldValue.Type())
+	}
+
+	return nil
+}
+
+// setSliceValue parses a list into a slice, replacing its previous contents.
+// Byte slices receive the raw string.
+func setSliceValue(fieldValue reflect.Value, value string) error {
+	if fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+		fieldValue.SetBytes([]byte(value))
+		return nil
+	}
+
+	items := splitList(value)
+	slice := reflect.MakeSlice(fieldValue.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := setFieldValue(slice.Index(i), item); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	fieldValue.Set(slice)
+	return nil
+}
+
+// setArrayValue parses a list into a fixed-size array.
+func setArrayValue(fieldValue reflect.Value, value string) error {
+	items := splitList(value)
+	if len(items) > fieldValue.Len() {
+		return fmt.Errorf("too many elements: got %d, array holds %d", len(items), fieldValue.Len())
+	}
+
+	array := reflect.New(fieldValue.Type()).Elem()
+	for i, item := range items {
+		if err := setFieldValue(array.Index(i), item); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	fieldValue.Set(array)
+	return nil
+}
+
+// setMapValue parses a "key=value" list into a map, replacing its previous contents.
+func setMapValue(fieldValue reflect.Value, value string) error {
+	mapType := fieldValue.Type()
+	result := reflect.MakeMap(mapType)
+	for _, item := range splitList(value) {
+		k, v, found := strings.Cut(item, "=")
+		if !found {
+			return fmt.Errorf("invalid map entry %q: expected key=value", item)
+		}
+
+		key := reflect.New(mapType.Key()).Elem()
+		if err := setFieldValue(key, strings.TrimSpace(k)); err != nil {
+			return fmt.Errorf("map key %q: %w", k, err)
+		}
+		elem := reflect.New(mapType.Elem()).Elem()
+		if err := setFieldValue(elem, strings.TrimSpace(v)); err != nil {
+			return fmt.Errorf("map value for %q: %w", k, err)
+		}
+		result.SetMapIndex(key, elem)
+	}
+	fieldValue.Set(result)
+	return nil
+}
+
+// splitList splits a list on semicolons if any are present, otherwise on commas.
+// Items are trimmed and empty items are dropped.
+func splitList(value string) []string {
+	separator := ","
+	if strings.Contains(value, ";") {
+		separator = ";"
+	}
+
+	parts := strings.Split(value, separator)
+	items := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// isTextUnmarshaler reports whether a pointer to t implements encoding.TextUnmarshaler.
+func isTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// isLeafType reports whether values of type t are set as a whole from a single
+// string rather than being treated as a nested configuration struct.
+func isLeafType(t reflect.Type) bool {
+	return isTextUnmarshaler(t) || t == urlType
+}
+
+// isNestedStruct reports whether t is a struct whose fields should be loaded individually.
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !isLeafType(t)
+}
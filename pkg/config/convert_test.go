@@ -0,0 +1,158 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetFieldValue(t *testing.T) {
+	type target struct {
+		String   string
+		Int      int
+		Int8     int8
+		Uint     uint
+		Float    float64
+		Bool     bool
+		Duration time.Duration
+		Strings  []string
+		Ints     []int
+		Bytes    []byte
+		Array    [2]int
+		Labels   map[string]string
+		Weights  map[string]float64
+		IntPtr   *int
+		IP       net.IP
+		URL      url.URL
+		URLPtr   *url.URL
+		Time     time.Time
+	}
+
+	intVal := 42
+
+	tests := []struct {
+		name    string
+		field   string
+		value   string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "string", field: "String", value: "hello", want: "hello"},
+		{name: "int", field: "Int", value: "-12", want: -12},
+		{name: "int8 overflow", field: "Int8", value: "300", wantErr: true},
+		{name: "uint", field: "Uint", value: "7", want: uint(7)},
+		{name: "negative uint", field: "Uint", value: "-7", wantErr: true},
+		{name: "float", field: "Float", value: "0.25", want: 0.25},
+		{name: "bool", field: "Bool", value: "true", want: true},
+		{name: "invalid bool", field: "Bool", value: "maybe", wantErr: true},
+		{name: "duration", field: "Duration", value: "1m30s", want: 90 * time.Second},
+		{name: "duration without unit", field: "Duration", value: "30", wantErr: true},
+		{name: "comma list", field: "Strings", value: "a, b ,c", want: []string{"a", "b", "c"}},
+		{name: "semicolon list", field: "Strings", value: "a,b;c", want: []string{"a,b", "c"}},
+		{name: "empty list", field: "Strings", value: " ", want: []string{}},
+		{name: "int list", field: "Ints", value: "1,2,3", want: []int{1, 2, 3}},
+		{name: "invalid int list", field: "Ints", value: "1,x", wantErr: true},
+		{name: "bytes", field: "Bytes", value: "raw,bytes", want: []byte("raw,bytes")},
+		{name: "array", field: "Array", value: "4;5", want: [2]int{4, 5}},
+		{name: "array overflow", field: "Array", value: "1,2,3", wantErr: true},
+		{name: "map", field: "Labels", value: "team=core, tier=1", want: map[string]string{"team": "core", "tier": "1"}},
+		{name: "map with semicolons", field: "Labels", value: "a=1,2;b=3", want: map[string]string{"a": "1,2", "b": "3"}},
+		{name: "typed map", field: "Weights", value: "a=0.5", want: map[string]float64{"a": 0.5}},
+		{name: "invalid map entry", field: "Labels", value: "novalue", wantErr: true},
+		{name: "pointer", field: "IntPtr", value: "42", want: &intVal},
+		{name: "text unmarshaler", field: "IP", value: "10.0.0.1", want: net.ParseIP("10.0.0.1")},
+		{name: "invalid text unmarshaler", field: "IP", value: "not-an-ip", wantErr: true},
+		{name: "url", field: "URL", value: "https://example.com/path", want: url.URL{Scheme: "https", Host: "example.com", Path: "/path"}},
+		{name: "url pointer", field: "URLPtr", value: "http://host", want: &url.URL{Scheme: "http", Host: "host"}},
+		{name: "time", field: "Time", value: "2024-01-02T03:04:05Z", want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg target
+			field := reflect.ValueOf(&cfg).Elem().FieldByName(tt.field)
+
+			err := setFieldValue(field, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setFieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := field.Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setFieldValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFieldValue_Unsupported(t *testing.T) {
+	var cfg struct {
+		Func func()
+	}
+	field := reflect.ValueOf(&cfg).Elem().Field(0)
+	if err := setFieldValue(field, "x"); err == nil {
+		t.Error("setFieldValue() should return error for unsupported type")
+	}
+}
+
+func TestSources_ComplexTypes(t *testing.T) {
+	type ProxyConfig struct {
+		Address string
+	}
+
+	type ComplexConfig struct {
+		Timeout      time.Duration     `config:"default=30s"`
+		AllowedHosts []string          `config:"default=a.example.com;b.example.com"`
+		Labels       map[string]string `config:"default=team=core"`
+		MaxConns     *int              `config:"default=10"`
+		Bind         net.IP            `config:"default=127.0.0.1"`
+		Endpoint     url.URL           `config:"default=http://localhost:8080"`
+		Proxy        *ProxyConfig
+		Unused       *ProxyConfig
+	}
+
+	os.Setenv("APP_TIMEOUT", "2m")
+	os.Setenv("APP_LABELS", "team=edge;tier=1")
+	os.Setenv("APP_PROXY_ADDRESS", "proxy:3128")
+	defer func() {
+		os.Unsetenv("APP_TIMEOUT")
+		os.Unsetenv("APP_LABELS")
+		os.Unsetenv("APP_PROXY_ADDRESS")
+	}()
+
+	loader := NewLoaderWithConfig(Config{EnvPrefix: "APP"})
+
+	var cfg ComplexConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Timeout != 2*time.Minute {
+		t.Errorf("cfg.Timeout = %v, want %v", cfg.Timeout, 2*time.Minute)
+	}
+	if want := []string{"a.example.com", "b.example.com"}; !reflect.DeepEqual(cfg.AllowedHosts, want) {
+		t.Errorf("cfg.AllowedHosts = %v, want %v", cfg.AllowedHosts, want)
+	}
+	if want := map[string]string{"team": "edge", "tier": "1"}; !reflect.DeepEqual(cfg.Labels, want) {
+		t.Errorf("cfg.Labels = %v, want %v", cfg.Labels, want)
+	}
+	if cfg.MaxConns == nil || *cfg.MaxConns != 10 {
+		t.Errorf("cfg.MaxConns = %v, want pointer to 10", cfg.MaxConns)
+	}
+	if !cfg.Bind.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("cfg.Bind = %v, want 127.0.0.1", cfg.Bind)
+	}
+	if cfg.Endpoint.Host != "localhost:8080" {
+		t.Errorf("cfg.Endpoint.Host = %q, want %q", cfg.Endpoint.Host, "localhost:8080")
+	}
+	if cfg.Proxy == nil || cfg.Proxy.Address != "proxy:3128" {
+		t.Errorf("cfg.Proxy = %+v, want Address from env", cfg.Proxy)
+	}
+	if cfg.Unused != nil {
+		t.Errorf("cfg.Unused = %+v, want nil when no env var is set", cfg.Unused)
+	}
+}
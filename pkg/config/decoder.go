@@ -1,14 +1,17 @@
 package config
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 
-	"gopkg.in/yaml.v3"
+	"github.com/pelletier/go-toml/v2"
 )
 
 // Decoder defines the interface for decoding configuration files.
@@ -16,7 +19,26 @@ type Decoder interface {
 	Decode(r io.Reader, v interface{}) error
 }
 
-// Format represents the configuration file format.
+// StrictDecoder is implemented by decoders that can reject keys matching no
+// field of v. All built-in decoders implement it; in strict mode, files in
+// formats whose decoder does not are decoded with Decode.
+//
+// Decoders report problems with a *DecodeError or DecodeErrors so that the
+// loader can add the file name to their position.
+type StrictDecoder interface {
+	Decoder
+	DecodeStrict(r io.Reader, v interface{}) error
+}
+
+// includeDecoder is implemented by the built-in YAML and JSON decoders,
+// which compose documents from several files (see includer). It returns the
+// composed document.
+type includeDecoder interface {
+	decodeIncludes(data []byte, v interface{}, strict bool, inc *includer) ([]byte, error)
+}
+
+// Format represents the configuration file format. Formats other than the
+// built-in ones can be added with RegisterDecoder.
 type Format string
 
 const (
@@ -24,6 +46,12 @@ const (
 	YAMLFormat Format = "yaml"
 	// JSONFormat represents JSON file format.
 	JSONFormat Format = "json"
+	// TOMLFormat represents TOML file format.
+	TOMLFormat Format = "toml"
+	// INIFormat represents INI file format.
+	INIFormat Format = "ini"
+	// PropertiesFormat represents Java .properties file format.
+	PropertiesFormat Format = "properties"
 	// UnknownFormat represents an unknown or unsupported format.
 	UnknownFormat Format = "unknown"
 )
@@ -36,9 +64,23 @@ func NewYAMLDecoder() Decoder {
 	return &yamlDecoder{}
 }
 
-// Decode decodes YAML data from the reader into v.
+// Decode decodes YAML data from the reader into v, ignoring unknown keys.
 func (d *yamlDecoder) Decode(r io.Reader, v interface{}) error {
-	return yaml.NewDecoder(r).Decode(v)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return decodeYAML(data, v, false)
+}
+
+// DecodeStrict decodes YAML data from the reader into v, reporting all
+// keys that match no field.
+func (d *yamlDecoder) DecodeStrict(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return decodeYAML(data, v, true)
 }
 
 // jsonDecoder decodes JSON files.
@@ -49,59 +91,214 @@ func NewJSONDecoder() Decoder {
 	return &jsonDecoder{}
 }
 
-// Decode decodes JSON data from the reader into v.
+// Decode decodes JSON data from the reader into v, ignoring unknown keys.
 func (d *jsonDecoder) Decode(r io.Reader, v interface{}) error {
-	return json.NewDecoder(r).Decode(v)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, v, false)
+}
+
+// DecodeStrict decodes JSON data from the reader into v, reporting all
+// keys that match no field.
+func (d *jsonDecoder) DecodeStrict(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, v, true)
+}
+
+// tomlDecoder decodes TOML files.
+type tomlDecoder struct{}
+
+// NewTOMLDecoder creates a new TOML decoder.
+func NewTOMLDecoder() Decoder {
+	return &tomlDecoder{}
+}
+
+// Decode decodes TOML data from the reader into v, ignoring unknown keys.
+func (d *tomlDecoder) Decode(r io.Reader, v interface{}) error {
+	return tomlError(toml.NewDecoder(r).Decode(v))
+}
+
+// DecodeStrict decodes TOML data from the reader into v, reporting all keys
+// that match no field other than the old names given by alias= options.
+func (d *tomlDecoder) DecodeStrict(r io.Reader, v interface{}) error {
+	decoder := toml.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+
+	var strictErr *toml.StrictMissingError
+	if aliases := aliasKeys(reflect.TypeOf(v)); len(aliases) > 0 && errors.As(err, &strictErr) {
+		unknown := strictErr.Errors[:0]
+		for _, missing := range strictErr.Errors {
+			if _, ok := unknownAliasKey(aliases, strings.Join(missing.Key(), ".")); !ok {
+				unknown = append(unknown, missing)
+			}
+		}
+		if len(unknown) == 0 {
+			return nil
+		}
+		strictErr.Errors = unknown
+	}
+	return tomlError(err)
+}
+
+// decoderRegistry maps formats to decoder factories and file extensions to formats.
+type decoderRegistry struct {
+	mu        sync.RWMutex
+	factories map[Format]func() Decoder
+	formats   map[string]Format
+}
+
+// decoders holds the built-in and registered decoders.
+var decoders = &decoderRegistry{
+	factories: map[Format]func() Decoder{
+		YAMLFormat:       NewYAMLDecoder,
+		JSONFormat:       NewJSONDecoder,
+		TOMLFormat:       NewTOMLDecoder,
+		INIFormat:        NewINIDecoder,
+		PropertiesFormat: NewPropertiesDecoder,
+	},
+	formats: map[string]Format{
+		"yaml":       YAMLFormat,
+		"yml":        YAMLFormat,
+		"json":       JSONFormat,
+		"toml":       TOMLFormat,
+		"ini":        INIFormat,
+		"properties": PropertiesFormat,
+	},
+}
+
+// RegisterDecoder registers a decoder for a format and associates the given
+// file extensions (with or without the leading dot, matched case-insensitively)
+// with it. Registering an existing format or extension replaces it, so
+// built-in decoders can be overridden. It panics on an empty or unknown
+// format name or a nil factory, since these are programming errors.
+//
+// Example:
+//
+//	config.RegisterDecoder("hcl", []string{"hcl"}, func() config.Decoder {
+//		return hclDecoder{}
+//	})
+func RegisterDecoder(format Format, exts []string, factory func() Decoder) {
+	if format == "" || format == UnknownFormat {
+		panic(fmt.Sprintf("config: invalid decoder format %q", format))
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("config: nil decoder factory for format %q", format))
+	}
+
+	decoders.mu.Lock()
+	defer decoders.mu.Unlock()
+	decoders.factories[format] = factory
+	for _, ext := range exts {
+		decoders.formats[normalizeExt(ext)] = format
+	}
+}
+
+// normalizeExt lower-cases an extension and strips its leading dot.
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
 }
 
 // DetectFormat detects the file format from the file extension.
 func DetectFormat(filename string) Format {
-	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
-	switch ext {
-	case "yaml", "yml":
-		return YAMLFormat
-	case "json":
-		return JSONFormat
-	default:
-		return UnknownFormat
+	decoders.mu.RLock()
+	defer decoders.mu.RUnlock()
+
+	if format, ok := decoders.formats[normalizeExt(filepath.Ext(filename))]; ok {
+		return format
 	}
+	return UnknownFormat
 }
 
 // NewDecoder creates a new decoder based on the file format.
 func NewDecoder(format Format) (Decoder, error) {
-	switch format {
-	case YAMLFormat:
-		return NewYAMLDecoder(), nil
-	case JSONFormat:
-		return NewJSONDecoder(), nil
-	default:
+	decoders.mu.RLock()
+	factory, ok := decoders.factories[format]
+	decoders.mu.RUnlock()
+
+	if !ok {
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
+	return factory(), nil
 }
 
 // DecodeFile decodes a configuration file into v.
 // The file format is automatically detected from the extension.
+// Decode errors are returned as *DecodeError or DecodeErrors with the
+// file name and position of each problem.
+//
+// YAML files may include other files with the !include tag, and JSON files
+// may reference values in other files with {"$ref": "file.json#/pointer"}.
+// Relative paths are resolved against the directory of the including file.
 func DecodeFile(path string, v interface{}) error {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("open file: %w", err)
 	}
-	defer file.Close()
+	return decodeData(path, data, v, false, nil, newFileIncluder(path, os.ReadFile))
+}
 
+// decodeData decodes the content of a configuration file into v, choosing
+// the decoder from the extension of path. If strict, keys that match no
+// field are errors. warn, if not nil, receives a warning for every
+// deprecated key in the file. inc, if not nil, resolves includes.
+func decodeData(path string, data []byte, v interface{}, strict bool, warn func(Warning), inc *includer) error {
 	format := DetectFormat(path)
 	if format == UnknownFormat {
 		return fmt.Errorf("unknown file format: %s", path)
 	}
+	return decodeFormat(path, format, data, v, strict, warn, inc)
+}
 
+// decodeFormat decodes data in the given format into v. path names the
+// input in decode errors and may be empty. Old names of fields, given with
+// the alias= tag option, are applied after the document is decoded. inc, if
+// not nil, resolves the includes of YAML and JSON documents and interpolates
+// the values of documents in every format.
+func decodeFormat(path string, format Format, data []byte, v interface{}, strict bool, warn func(Warning), inc *includer) error {
 	decoder, err := NewDecoder(format)
 	if err != nil {
 		return fmt.Errorf("create decoder: %w", err)
 	}
 
-	if err := decoder.Decode(file, v); err != nil {
-		return fmt.Errorf("decode file: %w", err)
+	var aliased []aliasedField
+	var previous reflect.Value
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		if aliased = aliasedFields(rv.Elem().Type()); len(aliased) > 0 {
+			previous = copyValue(rv.Elem())
+		}
+	}
+
+	id, resolvesIncludes := decoder.(includeDecoder)
+	if inc != nil && inc.interpolate && !resolvesIncludes {
+		if data, err = interpolateData(format, data, path, reflect.TypeOf(v)); err != nil {
+			return err
+		}
 	}
 
+	if resolvesIncludes && inc != nil {
+		data, err = id.decodeIncludes(data, v, strict, inc)
+	} else if sd, ok := decoder.(StrictDecoder); ok && strict {
+		err = sd.DecodeStrict(bytes.NewReader(data), v)
+	} else {
+		err = decoder.Decode(bytes.NewReader(data), v)
+	}
+	if err != nil {
+		return withFile(path, err)
+	}
+
+	if len(aliased) > 0 {
+		if warn == nil {
+			warn = func(Warning) {}
+		}
+		if err := applyFileAliases(aliased, format, data, reflect.ValueOf(v).Elem(), previous, warn); err != nil {
+			return withFile(path, err)
+		}
+	}
 	return nil
 }
-
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOMLDecoder(t *testing.T) {
+	type TOMLConfig struct {
+		Name     string
+		Tags     []string
+		Database struct {
+			Host     string
+			Port     int
+			MaxConns int `toml:"max_conns"`
+		}
+	}
+
+	input := `
+name = "billing"
+tags = ["a", "b"]
+
+[database]
+host = "db.local"
+port = 5432
+max_conns = 20
+`
+
+	var cfg TOMLConfig
+	if err := NewTOMLDecoder().Decode(strings.NewReader(input), &cfg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if cfg.Name != "billing" || !reflect.DeepEqual(cfg.Tags, []string{"a", "b"}) {
+		t.Errorf("Decode() = %+v, want name and tags", cfg)
+	}
+	if cfg.Database.Host != "db.local" || cfg.Database.Port != 5432 || cfg.Database.MaxConns != 20 {
+		t.Errorf("Decode() Database = %+v", cfg.Database)
+	}
+}
+
+// envFileDecoder decodes "KEY=value" lines into a fixed struct, for the registry test.
+type envFileDecoder struct{}
+
+func (d envFileDecoder) Decode(r io.Reader, v interface{}) error {
+	values := v.(*struct {
+		Name    string
+		Timeout time.Duration
+	})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "NAME":
+			values.Name = value
+		case "TIMEOUT":
+			if err := setFieldValue(reflect.ValueOf(&values.Timeout).Elem(), value); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	const envFileFormat Format = "test-envfile"
+	RegisterDecoder(envFileFormat, []string{".ENVFILE", "envf"}, func() Decoder { return envFileDecoder{} })
+
+	if got := DetectFormat("app.envfile"); got != envFileFormat {
+		t.Errorf("DetectFormat(app.envfile) = %v, want %v", got, envFileFormat)
+	}
+	if got := DetectFormat("app.ENVF"); got != envFileFormat {
+		t.Errorf("DetectFormat(app.ENVF) = %v, want %v", got, envFileFormat)
+	}
+	if _, err := NewDecoder(envFileFormat); err != nil {
+		t.Errorf("NewDecoder() error = %v", err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "app.envfile")
+	if err := os.WriteFile(filePath, []byte("NAME=billing\nTIMEOUT=5s\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var cfg struct {
+		Name    string
+		Timeout time.Duration
+	}
+	if err := DecodeFile(filePath, &cfg); err != nil {
+		t.Fatalf("DecodeFile() error = %v", err)
+	}
+	if cfg.Name != "billing" || cfg.Timeout != 5*time.Second {
+		t.Errorf("DecodeFile() = %+v", cfg)
+	}
+}
+
+func TestRegisterDecoder_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		factory func() Decoder
+	}{
+		{"empty format", "", NewJSONDecoder},
+		{"unknown format", UnknownFormat, NewJSONDecoder},
+		{"nil factory", "test-nil", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterDecoder() should panic")
+				}
+			}()
+			RegisterDecoder(tt.format, []string{"x"}, tt.factory)
+		})
+	}
+}
+
+func TestNewDecoder_Unsupported(t *testing.T) {
+	if _, err := NewDecoder("nonexistent"); err == nil {
+		t.Error("NewDecoder() should fail for an unregistered format")
+	}
+}
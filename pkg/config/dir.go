@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// k8sDataLink is the symlink through which Kubernetes publishes the current
+// contents of a mounted ConfigMap or Secret. Updates write a new timestamped
+// directory and atomically repoint the link.
+const k8sDataLink = "..data"
+
+// DirSource loads configuration from a directory holding one file per value,
+// such as a Kubernetes ConfigMap or Secret volume. File names are matched to
+// fields with the same naming rules as EnvSource, ignoring case: with prefix
+// APP, Database.Host is read from the file APP_DATABASE_HOST (or
+// app_database_host). Trailing newlines are removed from the values. Files
+// starting with "." and subdirectories are ignored.
+//
+// In a Kubernetes mount, the files are read through the directory the
+// "..data" symlink points to when the load starts, so an update landing
+// mid-load cannot mix old and new values, and Watch polls only that link to
+// notice an update.
+//
+// Example:
+//
+//	loader := config.NewLoader(config.WithSources(
+//		config.NewDefaultSource(),
+//		config.NewDirSource("/etc/billing"),
+//		config.NewDirSource("/run/secrets/billing"),
+//	))
+type DirSource struct {
+	Dir string
+	// Prefix, Separator and FlatNames name the files as for EnvSource.
+	Prefix    string
+	Separator string
+	FlatNames bool
+}
+
+// NewDirSource creates a new source reading the files in dir.
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{Dir: dir}
+}
+
+// Origin identifies the directory in Provenance. The path of the file each
+// field was read from is recorded as the origin's Key.
+func (s *DirSource) Origin() Origin {
+	return Origin{Source: "dir", Key: s.Dir}
+}
+
+// fingerprint writes the target of the "..data" link for Watch or, outside
+// Kubernetes mounts, the names and contents of the files.
+func (s *DirSource) fingerprint(w io.Writer) error {
+	if target, err := os.Readlink(filepath.Join(s.Dir, k8sDataLink)); err == nil {
+		fmt.Fprintf(w, "%s\x00%s\x00", s.Dir, target)
+		return nil
+	}
+
+	dir, files, err := s.files()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for _, name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\x00%d\x00", name, len(data))
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load loads configuration from the files in the directory.
+func (s *DirSource) Load(cfg interface{}) error {
+	_, err := s.loadReport(context.Background(), cfg)
+	return err
+}
+
+// loadReport loads the files and reports the path of the file each field
+// was read from and the deprecated files used.
+func (s *DirSource) loadReport(ctx context.Context, cfg interface{}) (loadReport, error) {
+	if err := checkConfigPointer(cfg); err != nil {
+		return loadReport{}, err
+	}
+	dir, files, err := s.files()
+	if errors.Is(err, fs.ErrNotExist) {
+		return loadReport{}, fmt.Errorf("directory not found: %s", s.Dir)
+	}
+	if err != nil {
+		return loadReport{}, fmt.Errorf("read directory: %w", err)
+	}
+
+	env := &EnvSource{
+		Prefix:    s.Prefix,
+		Separator: s.Separator,
+		FlatNames: s.FlatNames,
+		read: func(key string) (string, string, error) {
+			name, ok := files[strings.ToUpper(key)]
+			if !ok {
+				return "", "", nil
+			}
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return "", "", fmt.Errorf("read %s: %w", name, err)
+			}
+			return strings.TrimRight(string(data), "\r\n"), filepath.Join(s.Dir, name), nil
+		},
+	}
+	return env.loadReport(ctx, cfg)
+}
+
+// files resolves the directory to read, following the "..data" link if
+// there is one, and returns its regular files keyed by upper-cased name.
+func (s *DirSource) files() (string, map[string]string, error) {
+	dir := s.Dir
+	if target, err := os.Readlink(filepath.Join(s.Dir, k8sDataLink)); err == nil {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(s.Dir, target)
+		}
+		dir = target
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		// Stat follows symlinks, such as the per-key links of Kubernetes mounts
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files[strings.ToUpper(name)] = name
+	}
+	return dir, files, nil
+}
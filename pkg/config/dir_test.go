@@ -0,0 +1,163 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dirConfig struct {
+	Name     string
+	Port     int
+	Token    string `config:"env=API_TOKEN"`
+	Database struct {
+		Host     string
+		Password string
+	}
+}
+
+// writeConfigMap lays out files the way Kubernetes mounts a ConfigMap: the
+// data in a timestamped directory, a "..data" link to it and one link per key.
+func writeConfigMap(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	data := filepath.Join(dir, ".."+version)
+	if err := os.MkdirAll(data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(data, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join(k8sDataLink, name), link); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Swap the link atomically, as the kubelet does
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(".."+version, tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, k8sDataLink)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirSource_Load(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app_name":              "billing\n",
+		"APP_PORT":              "8080",
+		"APP_API_TOKEN":         "s3cr3t\r\n",
+		"APP_DATABASE_HOST":     "db.local",
+		"APP_DATABASE_PASSWORD": "",
+		".hidden":               "x",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "APP_SUB"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &DirSource{Dir: dir, Prefix: "APP"}
+	var cfg dirConfig
+	report, err := source.loadReport(context.Background(), &cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Name != "billing" || cfg.Port != 8080 || cfg.Token != "s3cr3t" || cfg.Database.Host != "db.local" {
+		t.Errorf("Load() = %+v", cfg)
+	}
+	if got := report.keys["Database.Host"]; got != filepath.Join(dir, "APP_DATABASE_HOST") {
+		t.Errorf("keys[Database.Host] = %q", got)
+	}
+	if got := report.keys["Name"]; got != filepath.Join(dir, "app_name") {
+		t.Errorf("keys[Name] = %q, want the file name as written", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "APP_PORT"), []byte("http"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.Load(&cfg); err == nil || !strings.Contains(err.Error(), `field "Port"`) {
+		t.Errorf("Load() error = %v, want conversion error for Port", err)
+	}
+
+	err = NewDirSource(filepath.Join(dir, "missing")).Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "directory not found") {
+		t.Errorf("Load() error = %v, want directory not found", err)
+	}
+}
+
+func TestDirSource_ConfigMap(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigMap(t, dir, "2024_01_01", map[string]string{"NAME": "billing\n", "PORT": "8080\n"})
+
+	l := NewLoader(WithSources(NewDirSource(dir))).(*loader)
+	var cfg dirConfig
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Name != "billing" || cfg.Port != 8080 {
+		t.Errorf("Load() = %+v", cfg)
+	}
+	if got := l.Provenance()["Port"].String(); got != "dir:"+filepath.Join(dir, "PORT") {
+		t.Errorf("Provenance()[Port] = %q", got)
+	}
+
+	before, err := l.sourcesFingerprint()
+	if err != nil {
+		t.Fatalf("sourcesFingerprint() error = %v", err)
+	}
+	writeConfigMap(t, dir, "2024_01_02", map[string]string{"NAME": "billing\n", "PORT": "9090\n"})
+	if after, _ := l.sourcesFingerprint(); after == before {
+		t.Error("sourcesFingerprint() should change when ..data is swapped")
+	}
+
+	cfg = dirConfig{}
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() after swap error = %v", err)
+	}
+	if cfg.Port != 9090 {
+		t.Errorf("Load() after swap = %+v, want port 9090", cfg)
+	}
+}
+
+func TestDirSource_Watch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "PORT"), []byte("8080\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLoader(WithSources(NewDirSource(dir)), WithWatchInterval(10*time.Millisecond))
+	var cfg dirConfig
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	changes := make(chan *dirConfig, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.(Watcher).Watch(ctx, &cfg, func(c interface{}) { changes <- c.(*dirConfig) })
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(dir, "NAME"), []byte("billing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-changes:
+		if got.Name != "billing" || got.Port != 8080 {
+			t.Errorf("reloaded config = %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+}
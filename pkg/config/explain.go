@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Explain renders a table of every configuration field with its current value
+// and the origin recorded in prov, typically obtained from ProvenanceReporter.Provenance:
+//
+//	FIELD              VALUE     ORIGIN
+//	Port               9090      env:APP_PORT
+//	Database.Host      db.local  file:config.yaml
+//	Database.Password  ******    env:APP_DATABASE_PASSWORD
+//	Timeout            30s       default
+//
+// Fields that no source set are shown with origin "-". Structs in slices,
+// arrays and maps get one row per field, such as "Upstreams[0].Host", with
+// the origin of the collection. Values of sensitive fields (see Redact), and
+// of every field below a sensitive struct, are masked, so the report is safe
+// to log.
+func Explain(cfg interface{}, prov Provenance) string {
+	rv := reflect.ValueOf(cfg)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ""
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tVALUE\tORIGIN")
+	explainStruct(w, rv, "", prov, false)
+	w.Flush()
+	return b.String()
+}
+
+// explainStruct writes one row per leaf field of a struct, in declaration
+// order. sensitive masks every field, for structs below a sensitive field.
+func explainStruct(w *tabwriter.Writer, rv reflect.Value, path string, prov Provenance, sensitive bool) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+		nested := isNestedStruct(field.Type) || field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem())
+		fieldSensitive := sensitive || isSensitiveField(field)
+		if fieldSensitive && !nested {
+			value := formatValue(fieldValue)
+			if !isZeroValue(fieldValue) {
+				value = maskedValue
+			}
+			explainRow(w, fieldPath, value, prov)
+			continue
+		}
+		explainValue(w, fieldValue, fieldPath, prov, fieldSensitive)
+	}
+}
+
+// explainValue writes the rows of a value: one per leaf field of the nested
+// structs it holds, directly, behind a non-nil pointer or as the elements of
+// a non-empty slice, array or map, indexed in path as "[i]" or "[key]", and a
+// single row otherwise. sensitive masks the fields of the nested structs.
+func explainValue(w *tabwriter.Writer, rv reflect.Value, path string, prov Provenance, sensitive bool) {
+	if hasNestedStruct(rv.Type()) {
+		switch rv.Kind() {
+		case reflect.Struct:
+			explainStruct(w, rv, path, prov, sensitive)
+			return
+		case reflect.Ptr:
+			if !rv.IsNil() {
+				explainValue(w, rv.Elem(), path, prov, sensitive)
+				return
+			}
+		case reflect.Slice, reflect.Array:
+			if rv.Len() > 0 {
+				for i := 0; i < rv.Len(); i++ {
+					explainValue(w, rv.Index(i), fmt.Sprintf("%s[%d]", path, i), prov, sensitive)
+				}
+				return
+			}
+		case reflect.Map:
+			if rv.Len() > 0 {
+				for _, key := range sortedMapKeys(rv) {
+					explainValue(w, rv.MapIndex(key), fmt.Sprintf("%s[%v]", path, key), prov, sensitive)
+				}
+				return
+			}
+		}
+	}
+	explainRow(w, path, formatValue(rv), prov)
+}
+
+// explainRow writes the row of a field with its origin.
+func explainRow(w *tabwriter.Writer, path, value string, prov Provenance) {
+	origin := "-"
+	if o, ok := prov.lookup(path); ok {
+		origin = o.String()
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\n", path, value, origin)
+}
+
+// formatValue formats a field value for a report, dereferencing pointers.
+func formatValue(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type explainDatabase struct {
+	Host     string `config:"default=localhost"`
+	Password string
+}
+
+type explainConfig struct {
+	Port     int           `config:"default=8080"`
+	Timeout  time.Duration `config:"default=30s"`
+	Name     string
+	APIToken string
+	Database explainDatabase
+	Cache    *explainDatabase
+}
+
+func TestLoader_Provenance(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.yaml")
+	content := "port: 9000\ndatabase:\n  host: db.local\n"
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	os.Setenv("EXPLAIN_PORT", "9090")
+	os.Setenv("EXPLAIN_DATABASE_PASSWORD", "hunter2")
+	os.Setenv("EXPLAIN_CACHE_HOST", "cache.local")
+	defer os.Unsetenv("EXPLAIN_PORT")
+	defer os.Unsetenv("EXPLAIN_DATABASE_PASSWORD")
+	defer os.Unsetenv("EXPLAIN_CACHE_HOST")
+
+	loader := NewLoaderWithConfig(Config{
+		FilePath:          filePath,
+		EnvPrefix:         "EXPLAIN",
+		ValidateAfterLoad: true,
+	})
+
+	var cfg explainConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	expected := Provenance{
+		"Port":              {Source: "env", Key: "EXPLAIN_PORT"},
+		"Timeout":           {Source: "default"},
+		"Database.Host":     {Source: "file", Key: filePath},
+		"Database.Password": {Source: "env", Key: "EXPLAIN_DATABASE_PASSWORD"},
+		"Cache.Host":        {Source: "env", Key: "EXPLAIN_CACHE_HOST"},
+	}
+	if prov := loader.(ProvenanceReporter).Provenance(); !reflect.DeepEqual(prov, expected) {
+		t.Errorf("Provenance() = %v, want %v", prov, expected)
+	}
+}
+
+func TestLoader_Provenance_ValuesEqualToCurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(filePath, []byte("database:\n  host: localhost\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Sources setting a value equal to the current one still set it
+	t.Setenv("UNCHANGED_PORT", "8080")
+	loader := NewLoaderWithConfig(Config{FilePath: filePath, EnvPrefix: "UNCHANGED"})
+
+	var cfg explainConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	prov := loader.(ProvenanceReporter).Provenance()
+	if got := prov["Port"]; got != (Origin{Source: "env", Key: "UNCHANGED_PORT"}) {
+		t.Errorf("Provenance()[Port] = %v, want env:UNCHANGED_PORT", got)
+	}
+	if got := prov["Database.Host"]; got != (Origin{Source: "file", Key: filePath}) {
+		t.Errorf("Provenance()[Database.Host] = %v, want file", got)
+	}
+}
+
+func TestLoader_Provenance_ReturnsCopy(t *testing.T) {
+	loader := NewLoader()
+
+	var cfg explainConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	prov := loader.(ProvenanceReporter).Provenance()
+	delete(prov, "Port")
+	if _, ok := loader.(ProvenanceReporter).Provenance()["Port"]; !ok {
+		t.Error("modifying the returned Provenance should not affect the loader")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	cfg := &explainConfig{
+		Port:     9090,
+		Timeout:  30 * time.Second,
+		APIToken: "abc123",
+		Database: explainDatabase{Host: "db.local", Password: "hunter2"},
+	}
+	prov := Provenance{
+		"Port":              {Source: "env", Key: "APP_PORT"},
+		"Timeout":           {Source: "default"},
+		"APIToken":          {Source: "env", Key: "APP_API_TOKEN"},
+		"Database.Host":     {Source: "file", Key: "config.yaml"},
+		"Database.Password": {Source: "env", Key: "APP_DATABASE_PASSWORD"},
+	}
+
+	report := Explain(cfg, prov)
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+
+	expected := [][]string{
+		{"FIELD", "VALUE", "ORIGIN"},
+		{"Port", "9090", "env:APP_PORT"},
+		{"Timeout", "30s", "default"},
+		{"Name", "", "-"},
+		{"APIToken", "******", "env:APP_API_TOKEN"},
+		{"Database.Host", "db.local", "file:config.yaml"},
+		{"Database.Password", "******", "env:APP_DATABASE_PASSWORD"},
+		{"Cache", "<nil>", "-"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Explain() returned %d lines, want %d:\n%s", len(lines), len(expected), report)
+	}
+	for i, want := range expected {
+		got := strings.Fields(lines[i])
+		wantFields := strings.Fields(strings.Join(want, " "))
+		if !reflect.DeepEqual(got, wantFields) {
+			t.Errorf("line %d = %q, want %q", i, got, wantFields)
+		}
+	}
+
+	if strings.Contains(report, "hunter2") || strings.Contains(report, "abc123") {
+		t.Errorf("Explain() leaked a sensitive value:\n%s", report)
+	}
+}
+
+func TestExplain_Collections(t *testing.T) {
+	type Upstream struct {
+		Host     string
+		Password string
+	}
+	type CollectionConfig struct {
+		Upstreams []Upstream
+		Backends  map[string]*Upstream
+		Empty     []Upstream
+	}
+
+	cfg := CollectionConfig{
+		Upstreams: []Upstream{{Host: "a.local", Password: "hunter2"}},
+		Backends:  map[string]*Upstream{"primary": {Host: "b.local", Password: "hunter2"}},
+	}
+	prov := Provenance{"Upstreams": {Source: "file", Key: "config.yaml"}}
+
+	report := Explain(cfg, prov)
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+
+	expected := [][]string{
+		{"FIELD", "VALUE", "ORIGIN"},
+		{"Upstreams[0].Host", "a.local", "file:config.yaml"},
+		{"Upstreams[0].Password", "******", "file:config.yaml"},
+		{"Backends[primary].Host", "b.local", "-"},
+		{"Backends[primary].Password", "******", "-"},
+		{"Empty", "[]", "-"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Explain() returned %d lines, want %d:\n%s", len(lines), len(expected), report)
+	}
+	for i, want := range expected {
+		if got := strings.Fields(lines[i]); !reflect.DeepEqual(got, want) {
+			t.Errorf("line %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestExplain_SensitiveStruct(t *testing.T) {
+	type Credentials struct {
+		User  string
+		Pass  string
+		Hosts []string
+	}
+	type ParentConfig struct {
+		DB      Credentials  `config:"secret"`
+		Replica *Credentials `config:"secret"`
+		Admin   Credentials
+	}
+
+	cfg := ParentConfig{
+		DB:      Credentials{User: "dbuser", Pass: "hunter2", Hosts: []string{"db.internal"}},
+		Replica: &Credentials{User: "replica", Pass: "hunter3"},
+		Admin:   Credentials{User: "root"},
+	}
+
+	report := Explain(cfg, Provenance{})
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+
+	expected := [][]string{
+		{"FIELD", "VALUE", "ORIGIN"},
+		{"DB.User", "******", "-"},
+		{"DB.Pass", "******", "-"},
+		{"DB.Hosts", "******", "-"},
+		{"Replica.User", "******", "-"},
+		{"Replica.Pass", "******", "-"},
+		{"Replica.Hosts", "[]", "-"},
+		{"Admin.User", "root", "-"},
+		{"Admin.Pass", "", "-"},
+		{"Admin.Hosts", "[]", "-"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Explain() returned %d lines, want %d:\n%s", len(lines), len(expected), report)
+	}
+	for i, want := range expected {
+		wantFields := strings.Fields(strings.Join(want, " "))
+		if got := strings.Fields(lines[i]); !reflect.DeepEqual(got, wantFields) {
+			t.Errorf("line %d = %q, want %q", i, got, wantFields)
+		}
+	}
+}
+
+func TestIsSensitiveField(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Password", true},
+		{"DBPassword", true},
+		{"ClientSecret", true},
+		{"AuthToken", true},
+		{"API_Key", true},
+		{"PrivateKey", true},
+		{"Credentials", true},
+		{"KeyFile", false},
+		{"Host", false},
+		{"TokenTTL", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := reflect.StructField{Name: tt.name}
+			if got := isSensitiveField(field); got != tt.want {
+				t.Errorf("isSensitiveField(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FlagSource loads configuration from command-line flags derived from the
+// config struct.
+//
+// Every leaf field gets a flag named after its kebab-case path: Database.Host
+// becomes --database-host and MaxConns becomes --max-conns. The desc= tag
+// option provides the usage text and default= the default shown in -help
+// (the default itself is applied by DefaultSource). Only flags given on the
+// command line are applied, so unset flags never override other sources.
+// Boolean fields can be set with a bare --flag. Slice and map flags may be
+// repeated: --hosts a --hosts b is the same as --hosts 'a;b'.
+//
+// Example:
+//
+//	type Config struct {
+//		Port     int `config:"default=8080,desc='HTTP listen port'"`
+//		Database struct {
+//			Host string `config:"desc='Database host name'"`
+//		}
+//	}
+//
+//	// app --port 9090 --database-host db.local
+//	source := config.NewFlagSource(os.Args[1:])
+type FlagSource struct {
+	// Args are the command-line arguments to parse, without the program name.
+	Args []string
+	// FlagSet, if set, receives the generated flags and parses Args, so that
+	// applications can define their own flags alongside them. By default a
+	// new flag.FlagSet with flag.ContinueOnError is used.
+	FlagSet *flag.FlagSet
+}
+
+// NewFlagSource creates a new command-line flag source.
+func NewFlagSource(args []string) *FlagSource {
+	return &FlagSource{Args: args}
+}
+
+// Origin identifies command-line flags in Provenance. The flag each field
+// was read from is recorded as the origin's Key.
+func (s *FlagSource) Origin() Origin {
+	return Origin{Source: "flag"}
+}
+
+// Load defines the flags for cfg, parses Args and applies the flags that
+// were given. A -help or -h argument yields an error wrapping flag.ErrHelp.
+func (s *FlagSource) Load(cfg interface{}) error {
+	_, err := s.loadReport(context.Background(), cfg)
+	return err
+}
+
+// loadReport loads the flags and reports the flag each field was read from.
+func (s *FlagSource) loadReport(_ context.Context, cfg interface{}) (loadReport, error) {
+	if err := checkConfigPointer(cfg); err != nil {
+		return loadReport{}, err
+	}
+	rv := reflect.ValueOf(cfg)
+
+	fs := s.FlagSet
+	if fs == nil {
+		fs = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	}
+
+	var flags []*fieldFlag
+	defineFlags(fs, rv.Elem().Type(), "", nil, make(map[reflect.Type]bool), &flags)
+
+	if err := fs.Parse(s.Args); err != nil {
+		return loadReport{}, err
+	}
+
+	report := loadReport{keys: make(map[string]string)}
+	for _, f := range flags {
+		if !f.set {
+			continue
+		}
+		field := fieldByIndexAlloc(rv.Elem(), f.index)
+		if err := setFieldValue(field, f.value()); err != nil {
+			return report, fmt.Errorf("flag --%s: %w", f.name, err)
+		}
+		report.keys[f.path] = "--" + f.name
+	}
+	return report, nil
+}
+
+// defineFlags defines a flag for every leaf field of struct type rt.
+// Flags already defined by an earlier Load on the same FlagSet are reset
+// and reused; names taken by the application's own flags are skipped.
+// seen holds the struct types on the current path, so that pointers to
+// them are skipped rather than followed forever.
+func defineFlags(fs *flag.FlagSet, rt reflect.Type, path string, index []int, seen map[reflect.Type]bool, flags *[]*fieldFlag) {
+	seen[rt] = true
+	defer delete(seen, rt)
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		switch {
+		case isNestedStruct(field.Type):
+			defineFlags(fs, field.Type, fieldPath, fieldIndex, seen, flags)
+			continue
+		case field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem()):
+			if !seen[field.Type.Elem()] {
+				defineFlags(fs, field.Type.Elem(), fieldPath, fieldIndex, seen, flags)
+			}
+			continue
+		}
+
+		name := flagName(fieldPath)
+		if existing := fs.Lookup(name); existing != nil {
+			if f, ok := existing.Value.(*fieldFlag); ok {
+				f.reset()
+				*flags = append(*flags, f)
+			}
+			continue
+		}
+
+		options := parseTagOptions(field.Tag.Get("config"))
+		f := &fieldFlag{
+			name:   name,
+			path:   fieldPath,
+			index:  fieldIndex,
+			kind:   field.Type.Kind(),
+			isBool: isBoolType(field.Type),
+			def:    options["default"],
+		}
+		fs.Var(f, name, options["desc"])
+		*flags = append(*flags, f)
+	}
+}
+
+// fieldFlag is a flag.Value that records the values given for a field.
+type fieldFlag struct {
+	name   string
+	path   string
+	index  []int
+	kind   reflect.Kind
+	isBool bool
+	// def is shown as the default in usage output.
+	def    string
+	values []string
+	set    bool
+}
+
+// String returns the default value, for usage output.
+func (f *fieldFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.def
+}
+
+// Set records a value given on the command line.
+func (f *fieldFlag) Set(value string) error {
+	f.values = append(f.values, value)
+	f.set = true
+	return nil
+}
+
+// IsBoolFlag lets boolean fields be set with a bare --flag.
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// value returns the value to store: the last occurrence, or all occurrences
+// joined by ";" for slices and maps.
+func (f *fieldFlag) value() string {
+	if f.kind == reflect.Slice || f.kind == reflect.Map {
+		return strings.Join(f.values, ";")
+	}
+	return f.values[len(f.values)-1]
+}
+
+// reset clears the values recorded by a previous parse.
+func (f *fieldFlag) reset() {
+	f.values = nil
+	f.set = false
+}
+
+// isBoolType reports whether t is bool or a pointer to bool.
+func isBoolType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// fieldByIndexAlloc returns the nested field at index, allocating nil
+// pointers to structs along the way.
+func fieldByIndexAlloc(rv reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(fieldIndex)
+	}
+	return rv
+}
+
+// flagName converts a dotted field path to a kebab-case flag name:
+// "Database.MaxConns" becomes "database-max-conns" and "TLSCert" "tls-cert".
+func flagName(path string) string {
+	var words []string
+	for _, segment := range strings.Split(path, ".") {
+		words = append(words, splitWords(segment)...)
+	}
+	return strings.ToLower(strings.Join(words, "-"))
+}
+
+// splitWords splits a Go identifier into words, keeping acronyms together:
+// "MaxConns" yields [Max Conns], "APIKey" [API Key] and "HTTP2Port" [HTTP2 Port].
+func splitWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if runes[i] == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		// A new word starts at an upper-case letter that follows a lower-case
+		// letter or digit ("maxConns"), or that ends an acronym ("APIKey").
+		prev := runes[i-1]
+		nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagTLS struct {
+	CertFile string `config:"desc='Path to the TLS certificate'"`
+}
+
+type flagConfig struct {
+	Port     int           `config:"default=8080,desc='HTTP listen port'"`
+	Debug    bool          `config:"desc=Enable debug logging"`
+	Timeout  time.Duration `config:"default=30s"`
+	Hosts    []string
+	APIKey   string `config:"secret"`
+	Database struct {
+		Host     string
+		MaxConns int
+	}
+	TLS *flagTLS
+}
+
+func TestFlagName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"Port", "port"},
+		{"MaxConns", "max-conns"},
+		{"Database.Host", "database-host"},
+		{"Database.MaxConns", "database-max-conns"},
+		{"APIKey", "api-key"},
+		{"TLS.CertFile", "tls-cert-file"},
+		{"HTTP2Port", "http2-port"},
+		{"UserID", "user-id"},
+		{"Max_Conns", "max-conns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := flagName(tt.path); got != tt.want {
+				t.Errorf("flagName(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagSource_Load(t *testing.T) {
+	source := NewFlagSource([]string{
+		"--port", "9090",
+		"--debug",
+		"-timeout=5s",
+		"--hosts", "a,b", "--hosts", "c",
+		"--database-host=db.local",
+	})
+
+	cfg := flagConfig{Database: struct {
+		Host     string
+		MaxConns int
+	}{MaxConns: 10}}
+	report, err := source.loadReport(context.Background(), &cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Port != 9090 || !cfg.Debug || cfg.Timeout != 5*time.Second {
+		t.Errorf("Load() = %+v, want port, debug and timeout from flags", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []string{"a,b", "c"}) {
+		t.Errorf("cfg.Hosts = %q, want repeated flags as separate items", cfg.Hosts)
+	}
+	if cfg.Database.Host != "db.local" || cfg.Database.MaxConns != 10 {
+		t.Errorf("cfg.Database = %+v, want host from flag and MaxConns kept", cfg.Database)
+	}
+	if cfg.TLS != nil {
+		t.Error("cfg.TLS should stay nil when none of its flags is given")
+	}
+
+	expected := map[string]string{
+		"Port":          "--port",
+		"Debug":         "--debug",
+		"Timeout":       "--timeout",
+		"Hosts":         "--hosts",
+		"Database.Host": "--database-host",
+	}
+	if !reflect.DeepEqual(report.keys, expected) {
+		t.Errorf("keys = %v, want %v", report.keys, expected)
+	}
+
+	if err := NewFlagSource([]string{"--tls-cert-file", "cert.pem"}).Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.TLS == nil || cfg.TLS.CertFile != "cert.pem" {
+		t.Errorf("cfg.TLS = %+v, want allocated with CertFile", cfg.TLS)
+	}
+}
+
+func TestFlagSource_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown flag", []string{"--nope"}},
+		{"invalid value", []string{"--port", "http"}},
+		{"missing value", []string{"--port"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("app", flag.ContinueOnError)
+			fs.SetOutput(&bytes.Buffer{})
+			source := &FlagSource{Args: tt.args, FlagSet: fs}
+
+			var cfg flagConfig
+			if err := source.Load(&cfg); err == nil {
+				t.Error("Load() should fail")
+			}
+		})
+	}
+}
+
+func TestFlagSource_Help(t *testing.T) {
+	var usage bytes.Buffer
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(&usage)
+
+	var cfg flagConfig
+	err := (&FlagSource{Args: []string{"-h"}, FlagSet: fs}).Load(&cfg)
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Load() error = %v, want flag.ErrHelp", err)
+	}
+
+	for _, want := range []string{"-port", "HTTP listen port (default 8080)", "-database-max-conns", "Path to the TLS certificate", "-api-key"} {
+		if !strings.Contains(usage.String(), want) {
+			t.Errorf("usage output missing %q:\n%s", want, usage.String())
+		}
+	}
+}
+
+func TestFlagSource_SharedFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	version := fs.Bool("version", false, "print the version")
+	port := fs.Int("port", 0, "application-defined port flag")
+
+	source := &FlagSource{Args: []string{"--version", "--port", "1", "--debug"}, FlagSet: fs}
+
+	var cfg flagConfig
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !*version || *port != 1 {
+		t.Errorf("application flags = %v, %d, want true, 1", *version, *port)
+	}
+	if cfg.Port != 0 || !cfg.Debug {
+		t.Errorf("cfg = %+v, want application's --port left alone and --debug applied", cfg)
+	}
+
+	// Loading again reuses the flags and forgets earlier values.
+	source.Args = []string{"--database-host", "db.local"}
+	var again flagConfig
+	if err := source.Load(&again); err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+	if again.Debug || again.Database.Host != "db.local" {
+		t.Errorf("second Load() = %+v, want only --database-host applied", again)
+	}
+}
+
+func TestLoader_Load_Args(t *testing.T) {
+	os.Setenv("FLAGS_PORT", "7000")
+	os.Setenv("FLAGS_DATABASE_HOST", "env.local")
+	defer os.Unsetenv("FLAGS_PORT")
+	defer os.Unsetenv("FLAGS_DATABASE_HOST")
+
+	loader := NewLoaderWithConfig(Config{
+		EnvPrefix: "FLAGS",
+		Args:      []string{"--port", "9090"},
+	})
+
+	var cfg flagConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Port != 9090 {
+		t.Errorf("cfg.Port = %d, want flag to override env", cfg.Port)
+	}
+	if cfg.Database.Host != "env.local" {
+		t.Errorf("cfg.Database.Host = %q, want env value kept", cfg.Database.Host)
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("cfg.Timeout = %v, want default", cfg.Timeout)
+	}
+
+	prov := loader.(ProvenanceReporter).Provenance()
+	if prov["Port"].String() != "flag:--port" || prov["Database.Host"].String() != "env:FLAGS_DATABASE_HOST" {
+		t.Errorf("Provenance() = %v", prov)
+	}
+}
+
+func TestFlagSource_RecursiveType(t *testing.T) {
+	var node recursiveNode
+	err := NewFlagSource([]string{"--name", "root"}).Load(&node)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if node.Name != "root" || node.Child != nil {
+		t.Errorf("Load() = %+v, want name set and recursive pointer skipped", node)
+	}
+
+	// Flags of the recursive pointer are not defined
+	err = NewFlagSource([]string{"--child-name", "leaf"}).Load(&recursiveNode{})
+	if err == nil || !strings.Contains(err.Error(), "child-name") {
+		t.Errorf("Load() error = %v, want undefined flag", err)
+	}
+
+	node = recursiveNode{}
+	if err := NewLoaderWithConfig(Config{Args: []string{"--name", "root"}}).Load(&node); err != nil {
+		t.Fatalf("Loader.Load() error = %v", err)
+	}
+	if node.Name != "root" {
+		t.Errorf("Loader.Load() = %+v, want name from flag", node)
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"sync"
+)
+
+// FSSource loads configuration from a file in an fs.FS, such as an embed.FS
+// holding default configuration compiled into the binary, or an
+// fstest.MapFS in tests. The format is detected from the file extension, as
+// for FileSource, and includes are resolved within FS.
+//
+// Example:
+//
+//	//go:embed defaults.yaml
+//	var defaults embed.FS
+//
+//	loader := config.NewLoader(config.WithSources(
+//		config.NewDefaultSource(),
+//		config.NewFSSource(defaults, "defaults.yaml"),
+//		config.NewFileSource("/etc/app/config.yaml"),
+//		config.NewEnvSource("APP"),
+//	))
+type FSSource struct {
+	FS fs.FS
+	// Path is the slash-separated path of the file within FS.
+	Path string
+	// Strict makes keys that match no field an error.
+	Strict bool
+}
+
+// NewFSSource creates a new source reading path from fsys.
+func NewFSSource(fsys fs.FS, path string) *FSSource {
+	return &FSSource{FS: fsys, Path: path}
+}
+
+// Origin identifies the file in Provenance.
+func (s *FSSource) Origin() Origin {
+	return Origin{Source: "fs", Key: s.Path}
+}
+
+// fingerprint writes the paths and content of the file and of the files it
+// includes for Watch.
+func (s *FSSource) fingerprint(w io.Writer) error {
+	data, err := fs.ReadFile(s.FS, s.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s\x00%d\x00", s.Path, len(data))
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	inc := newFSIncluder(s.FS, s.Path)
+	inc.scan(DetectFormat(s.Path), data)
+	return inc.fingerprint(w)
+}
+
+// Load loads configuration from the file.
+func (s *FSSource) Load(cfg interface{}) error {
+	_, err := s.loadReport(context.Background(), cfg)
+	return err
+}
+
+// loadReport loads the file and reports the file, or included file, each
+// field was read from and the deprecated keys used.
+func (s *FSSource) loadReport(_ context.Context, cfg interface{}) (loadReport, error) {
+	var report loadReport
+	data, err := fs.ReadFile(s.FS, s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return report, fmt.Errorf("file not found: %s", s.Path)
+	}
+	if err != nil {
+		return report, fmt.Errorf("open file: %w", err)
+	}
+	inc := newFSIncluder(s.FS, s.Path)
+	if err := decodeData(s.Path, data, cfg, s.Strict, func(w Warning) { report.warns = append(report.warns, w) }, inc); err != nil {
+		return report, err
+	}
+	report.keys = inc.fieldKeys(reflect.TypeOf(cfg), DetectFormat(s.Path), data, s.Path)
+	return report, nil
+}
+
+// DecodeFS decodes a configuration file in fsys into v.
+// The file format is automatically detected from the extension.
+func DecodeFS(fsys fs.FS, path string, v interface{}) error {
+	return NewFSSource(fsys, path).Load(v)
+}
+
+// ReaderSource loads configuration in a given format from an io.Reader.
+// The reader is read once, on the first Load; later loads, such as reloads
+// by Watch, decode the same content again.
+type ReaderSource struct {
+	Reader io.Reader
+	Format Format
+	// Name identifies the content in Provenance and decode errors (optional).
+	Name string
+	// Strict makes keys that match no field an error.
+	Strict bool
+
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// NewReaderSource creates a new source decoding r in the given format.
+func NewReaderSource(r io.Reader, format Format) *ReaderSource {
+	return &ReaderSource{Reader: r, Format: format}
+}
+
+// Origin identifies the reader in Provenance.
+func (s *ReaderSource) Origin() Origin {
+	return Origin{Source: "reader", Key: s.Name}
+}
+
+// Load reads the content, on the first call, and decodes it.
+func (s *ReaderSource) Load(cfg interface{}) error {
+	_, err := s.loadReport(context.Background(), cfg)
+	return err
+}
+
+// loadReport reads the content, on the first call, decodes it and reports
+// the deprecated keys used.
+func (s *ReaderSource) loadReport(_ context.Context, cfg interface{}) (loadReport, error) {
+	var report loadReport
+	s.once.Do(func() {
+		s.data, s.err = io.ReadAll(s.Reader)
+	})
+	if s.err != nil {
+		return report, fmt.Errorf("read: %w", s.err)
+	}
+	err := decodeFormat(s.Name, s.Format, s.data, cfg, s.Strict, func(w Warning) { report.warns = append(report.warns, w) }, nil)
+	return report, err
+}
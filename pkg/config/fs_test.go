@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type fsConfig struct {
+	Name string `config:"default=app"`
+	Port int
+	Host string
+}
+
+func TestFSSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.yaml":      {Data: []byte("name: embedded\nport: 8080\n")},
+		"conf/override.json": {Data: []byte(`{"port": 9090}`)},
+		"typo.yaml":          {Data: []byte("name: a\nprot: 1\n")},
+	}
+
+	var cfg fsConfig
+	if err := NewFSSource(fsys, "defaults.yaml").Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := DecodeFS(fsys, "conf/override.json", &cfg); err != nil {
+		t.Fatalf("DecodeFS() error = %v", err)
+	}
+	if cfg.Name != "embedded" || cfg.Port != 9090 {
+		t.Errorf("cfg = %+v, want name from YAML and port from JSON", cfg)
+	}
+
+	err := NewFSSource(fsys, "missing.yaml").Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "file not found: missing.yaml") {
+		t.Errorf("Load() error = %v, want file not found", err)
+	}
+
+	source := &FSSource{FS: fsys, Path: "typo.yaml", Strict: true}
+	err = source.Load(&cfg)
+	if err == nil || err.Error() != `typo.yaml:2:1: unknown key "prot"` {
+		t.Errorf("Load() error = %v, want unknown key with position", err)
+	}
+}
+
+func TestReaderSource(t *testing.T) {
+	source := NewReaderSource(strings.NewReader("port = 7070\nhost = \"db\"\n"), TOMLFormat)
+
+	// The content is kept for later loads
+	for i := 0; i < 2; i++ {
+		var cfg fsConfig
+		if err := source.Load(&cfg); err != nil {
+			t.Fatalf("Load() #%d error = %v", i+1, err)
+		}
+		if cfg.Port != 7070 || cfg.Host != "db" {
+			t.Errorf("Load() #%d = %+v", i+1, cfg)
+		}
+	}
+
+	var cfg fsConfig
+	err := NewReaderSource(strings.NewReader("port: x\n"), YAMLFormat).Load(&cfg)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Line != 1 || decodeErr.Column != 7 {
+		t.Errorf("Load() error = %v, want positioned decode error", err)
+	}
+
+	if err := NewReaderSource(strings.NewReader(""), Format("xml")).Load(&cfg); err == nil {
+		t.Error("Load() should fail for an unsupported format")
+	}
+}
+
+func TestLoader_WithFSAndReaderSources(t *testing.T) {
+	fsys := fstest.MapFS{"defaults.yaml": {Data: []byte("name: embedded\nport: 8080\nhost: localhost\n")}}
+	override := &ReaderSource{Reader: strings.NewReader(`{"host": "db.local"}`), Format: JSONFormat, Name: "override"}
+
+	layered := NewLoader(WithSources(NewDefaultSource(), NewFSSource(fsys, "defaults.yaml"), override))
+	var cfg fsConfig
+	if err := layered.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Name != "embedded" || cfg.Port != 8080 || cfg.Host != "db.local" {
+		t.Errorf("Load() = %+v", cfg)
+	}
+
+	prov := layered.(ProvenanceReporter).Provenance()
+	if prov["Port"].String() != "fs:defaults.yaml" || prov["Host"].String() != "reader:override" {
+		t.Errorf("Provenance() = %v", prov)
+	}
+
+	// FS files can be watched
+	l := NewLoader(WithSources(NewFSSource(fsys, "defaults.yaml"))).(*loader)
+	before, err := l.sourcesFingerprint()
+	if err != nil {
+		t.Fatalf("sourcesFingerprint() error = %v", err)
+	}
+	fsys["defaults.yaml"] = &fstest.MapFile{Data: []byte("port: 1\n")}
+	if after, _ := l.sourcesFingerprint(); after == before {
+		t.Error("sourcesFingerprint() should change when the FS file changes")
+	}
+}
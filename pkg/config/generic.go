@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Load creates a loader with the given options, loads a configuration of
+// type T and returns it. T must be a struct type. On error the zero value of
+// T is returned.
+//
+// Example:
+//
+//	cfg, err := config.Load[AppConfig](
+//		config.WithFile("config.yaml"),
+//		config.WithEnvPrefix("APP"),
+//		config.WithArgs(os.Args[1:]),
+//	)
+func Load[T any](opts ...Option) (T, error) {
+	var cfg T
+	if t := reflect.TypeOf((*T)(nil)).Elem(); t.Kind() != reflect.Struct {
+		return cfg, fmt.Errorf("config.Load: type %s is not a struct", t)
+	}
+
+	if err := NewLoader(opts...).Load(&cfg); err != nil {
+		var zero T
+		return zero, err
+	}
+	return cfg, nil
+}
+
+// MustLoad is like Load but panics if the configuration cannot be loaded,
+// with an error wrapping the error of Load. It is intended for program
+// startup.
+//
+// Example:
+//
+//	var cfg = config.MustLoad[AppConfig](config.WithEnvPrefix("APP"))
+func MustLoad[T any](opts ...Option) T {
+	cfg, err := Load[T](opts...)
+	if err != nil {
+		panic(fmt.Errorf("config: %w", err))
+	}
+	return cfg
+}
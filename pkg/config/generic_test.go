@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type genericConfig struct {
+	Name  string `config:"default=app"`
+	Port  int    `config:"default=8080,validate=range=1,65535"`
+	Debug bool
+	Mode  string `config:"validate=mode"`
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.yaml": "name: billing\nport: 9000\n"})
+
+	os.Setenv("GENERIC_PORT", "9100")
+	defer os.Unsetenv("GENERIC_PORT")
+
+	cfg, err := Load[genericConfig](
+		WithFile(filepath.Join(dir, "config.yaml")),
+		WithEnvPrefix("GENERIC"),
+		WithArgs([]string{"--debug"}),
+		WithRule("mode", func(value interface{}, _ string) error { return nil }),
+	)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := genericConfig{Name: "billing", Port: 9100, Debug: true}
+	if cfg != want {
+		t.Errorf("Load() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	if _, err := Load[*genericConfig](); err == nil || !strings.Contains(err.Error(), "*config.genericConfig is not a struct") {
+		t.Errorf("Load[*T]() error = %v, want non-struct error", err)
+	}
+	if _, err := Load[map[string]string](); err == nil {
+		t.Error("Load[map]() should fail")
+	}
+
+	// Validation failures return the zero value
+	os.Setenv("GENERIC_PORT", "0")
+	defer os.Unsetenv("GENERIC_PORT")
+
+	anyMode := func(value interface{}, _ string) error { return nil }
+	cfg, err := Load[genericConfig](WithEnvPrefix("GENERIC"), WithRule("mode", anyMode))
+	if err == nil || !strings.Contains(err.Error(), "Port") {
+		t.Errorf("Load() error = %v, want validation error for Port", err)
+	}
+	if cfg != (genericConfig{}) {
+		t.Errorf("Load() = %+v, want zero value on error", cfg)
+	}
+
+	cfg, err = Load[genericConfig](WithEnvPrefix("GENERIC"), WithoutValidation())
+	if err != nil || cfg.Port != 0 {
+		t.Errorf("Load() = %+v, %v, want validation skipped", cfg, err)
+	}
+}
+
+func TestMustLoad(t *testing.T) {
+	cfg := MustLoad[genericConfig](WithoutValidation())
+	if cfg.Name != "app" || cfg.Port != 8080 {
+		t.Errorf("MustLoad() = %+v, want defaults", cfg)
+	}
+
+	t.Setenv("GENERIC_PORT", "70000")
+	defer func() {
+		var errs ValidationErrors
+		err, ok := recover().(error)
+		if !ok || !errors.As(err, &errs) {
+			t.Errorf("MustLoad() panicked with %v, want an error wrapping the validation errors", err)
+		}
+	}()
+	MustLoad[genericConfig](WithEnvPrefix("GENERIC"))
+}
+
+func TestSources_RequirePointer(t *testing.T) {
+	for _, source := range []Source{NewEnvSource("APP"), NewDefaultSource(), NewFlagSource(nil)} {
+		if err := source.Load(genericConfig{}); err == nil {
+			t.Errorf("%T.Load() should reject a struct passed by value", source)
+		}
+	}
+
+	var nilCfg *genericConfig
+	if err := NewLoader().Load(nilCfg); err == nil || !strings.Contains(err.Error(), "non-nil pointer to a struct") {
+		t.Errorf("Load() error = %v, want pointer error", err)
+	}
+}
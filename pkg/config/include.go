@@ -0,0 +1,602 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeTag marks a YAML node replaced by the document of another file:
+//
+//	database: !include database.yaml
+const includeTag = "!include"
+
+// refKey is the key of a JSON object replaced by the value it references:
+//
+//	"database": {"$ref": "database.json#/primary"}
+const refKey = "$ref"
+
+// includer resolves YAML !include tags and JSON $ref objects while a file is
+// decoded. References are relative to the file that contains them.
+type includer struct {
+	// read reads a file, join resolves a reference against the file that
+	// contains it.
+	read func(name string) ([]byte, error)
+	join func(base, ref string) string
+
+	// stack holds the files, and JSON pointers, being decoded, outermost
+	// first, to detect cycles.
+	stack []includeFrame
+	// files caches the content of the files read.
+	files map[string][]byte
+	// keys maps the dotted document keys replaced by an include to the
+	// included file.
+	keys map[string]string
+	// lines maps the dotted document keys of the strings holding references
+	// to their line, for errors: the composed document has no positions.
+	lines map[string]int
+	// interpolate expands variable references in the values of the
+	// documents, including the references of includes.
+	interpolate bool
+}
+
+// includeFrame is a file, or a JSON pointer within a file, being decoded.
+type includeFrame struct {
+	name    string
+	pointer string
+}
+
+// String formats the frame as in a $ref, e.g. "database.json#/primary".
+func (f includeFrame) String() string {
+	if f.pointer == "" {
+		return f.name
+	}
+	return f.name + "#" + f.pointer
+}
+
+// newFileIncluder creates an includer for the file at path, reading
+// included files with read and resolving relative paths against the
+// directory of the including file.
+func newFileIncluder(path string, read func(name string) ([]byte, error)) *includer {
+	return newIncluder(filepath.Clean(path), read, func(base, ref string) string {
+		if filepath.IsAbs(ref) {
+			return filepath.Clean(ref)
+		}
+		return filepath.Join(filepath.Dir(base), ref)
+	})
+}
+
+// newFSIncluder creates an includer for the file name in fsys.
+func newFSIncluder(fsys fs.FS, name string) *includer {
+	read := func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+	return newIncluder(path.Clean(name), read, func(base, ref string) string {
+		return path.Join(path.Dir(base), ref)
+	})
+}
+
+// newIncluder creates an includer for the file name.
+func newIncluder(name string, read func(string) ([]byte, error), join func(base, ref string) string) *includer {
+	return &includer{
+		read:  read,
+		join:  join,
+		stack: []includeFrame{{name: name}},
+		files: make(map[string][]byte),
+		keys:  make(map[string]string),
+		lines: make(map[string]int),
+	}
+}
+
+// current returns the name of the file being decoded.
+func (inc *includer) current() string {
+	return inc.stack[len(inc.stack)-1].name
+}
+
+// open reads the file a reference names, relative to the current file, and
+// makes it the current file until close is called. A reference may end with
+// "#" and a JSON pointer, and names the current file if it starts with "#".
+// Including a file, or pointer, that is already being decoded is an error.
+func (inc *includer) open(ref string) (includeFrame, []byte, error) {
+	name, pointer, _ := strings.Cut(ref, "#")
+	frame := includeFrame{name: inc.current(), pointer: pointer}
+	if name != "" {
+		frame.name = inc.join(inc.current(), name)
+	}
+
+	for i, open := range inc.stack {
+		if open == frame {
+			cycle := make([]string, 0, len(inc.stack)-i+1)
+			for _, f := range append(inc.stack[i:], frame) {
+				cycle = append(cycle, f.String())
+			}
+			return frame, nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	data, ok := inc.files[frame.name]
+	if !ok {
+		var err error
+		if data, err = inc.read(frame.name); err != nil {
+			return frame, nil, err
+		}
+		inc.files[frame.name] = data
+	}
+	inc.stack = append(inc.stack, frame)
+	return frame, data, nil
+}
+
+// close returns to the file that contains the last opened reference.
+func (inc *includer) close() {
+	inc.stack = inc.stack[:len(inc.stack)-1]
+}
+
+// record notes that the document key path was replaced by an included file.
+// Keys within sequences are not recorded, since provenance does not track
+// elements.
+func (inc *includer) record(key, name string) {
+	if key != "" && !strings.Contains(key, "[") {
+		inc.keys[key] = name
+	}
+}
+
+// scan reads the files a document includes, without decoding it, so that
+// fingerprint covers them. Includes that fail to resolve are skipped.
+func (inc *includer) scan(format Format, data []byte) {
+	switch format {
+	case YAMLFormat:
+		var root yaml.Node
+		if yaml.Unmarshal(data, &root) == nil {
+			if inc.interpolate {
+				_ = interpolateYAML(&root, inc.current())
+			}
+			_ = inc.includeYAML(&root, "", make(map[*yaml.Node]string))
+		}
+	case JSONFormat:
+		var tree interface{}
+		if json.Unmarshal(data, &tree) == nil {
+			_, _ = inc.resolveJSON(tree, "")
+		}
+	}
+}
+
+// fingerprint writes the names and content of the files read, for Watch.
+func (inc *includer) fingerprint(w io.Writer) error {
+	names := make([]string, 0, len(inc.files))
+	for name := range inc.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\x00%d\x00", name, len(inc.files[name]))
+		if _, err := w.Write(inc.files[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldKeys returns the keys of the fields set by the document data, for
+// Provenance: fields read from included files map to those files and the
+// other fields of the document to key. t is the type decoded into.
+func (inc *includer) fieldKeys(t reflect.Type, format Format, data []byte, key string) map[string]string {
+	keys := documentKeys(t, format, data, key)
+	for key, name := range inc.keys {
+		if path, ok := keyFieldPath(t, key, format); ok {
+			keys[path] = name
+		}
+	}
+	return keys
+}
+
+// keyFieldPath returns the dotted field path that a dotted document key
+// decodes into, e.g. "Database.Primary" for "database.primary". Keys below a
+// map or slice have no field path.
+func keyFieldPath(t reflect.Type, key string, format Format) (string, bool) {
+	var fieldPath string
+	for _, segment := range strings.Split(key, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if !isNestedStruct(t) {
+			return "", false
+		}
+
+		field, ok := documentField(t, segment, format)
+		if !ok {
+			return "", false
+		}
+		fieldPath, t = joinPath(fieldPath, field.Name), field.Type
+	}
+	return fieldPath, fieldPath != ""
+}
+
+// documentField returns the exported field of struct type t that the
+// document key name decodes into, matching case-insensitively.
+func documentField(t reflect.Type, name string, format Format) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := fileKey(field)
+		if format == JSONFormat {
+			key = jsonKey(field)
+		}
+		if field.IsExported() && key != "" && strings.EqualFold(key, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// decodeIncludes decodes a YAML document into v, replacing !include nodes
+// with the documents of the files they name. It returns the composed
+// document, for aliases.
+func (d *yamlDecoder) decodeIncludes(data []byte, v interface{}, strict bool, inc *includer) ([]byte, error) {
+	if !bytes.Contains(data, []byte(includeTag)) && !inc.interpolate {
+		return data, decodeYAML(data, v, strict)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, yamlError(err)
+	}
+	if inc.interpolate {
+		if err := interpolateYAML(&root, inc.current()); err != nil {
+			return nil, err
+		}
+	}
+	files := make(map[*yaml.Node]string)
+	if err := inc.includeYAML(&root, "", files); err != nil {
+		return nil, err
+	}
+
+	if err := checkYAML(&root, v, strict, files); err != nil {
+		return nil, err
+	}
+	// Unknown keys were reported by the check.
+	if err := root.Decode(v); err != nil {
+		return nil, yamlError(err)
+	}
+	return yaml.Marshal(&root)
+}
+
+// includeYAML replaces the !include nodes at or below node with the root
+// node of the documents they name. path is the dotted key path of node, and
+// files receives the file of each replaced node.
+func (inc *includer) includeYAML(node *yaml.Node, path string, files map[*yaml.Node]string) error {
+	switch {
+	case node.Tag == includeTag:
+		return inc.includeYAMLFile(node, path, files)
+	case node.Kind == yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := inc.includeYAML(child, path, files); err != nil {
+				return err
+			}
+		}
+	case node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			valuePath := joinKey(path, key.Value)
+			if key.Tag == "!!merge" {
+				valuePath = path
+			}
+			if err := inc.includeYAML(value, valuePath, files); err != nil {
+				return err
+			}
+		}
+	case node.Kind == yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := inc.includeYAML(child, fmt.Sprintf("%s[%d]", path, i), files); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// includeYAMLFile replaces an !include node with the root node of the
+// document it names, resolving includes in that document too.
+func (inc *includer) includeYAMLFile(node *yaml.Node, path string, files map[*yaml.Node]string) error {
+	fail := func(message string) error {
+		return &DecodeError{File: inc.current(), Line: node.Line, Column: node.Column, Message: prefixKey(path, message)}
+	}
+	if node.Kind != yaml.ScalarNode || node.Value == "" {
+		return fail(includeTag + " requires a file name")
+	}
+
+	frame, data, err := inc.open(node.Value)
+	if err != nil {
+		return fail(fmt.Sprintf("%s %s: %v", includeTag, node.Value, err))
+	}
+	defer inc.close()
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return withFile(frame.name, yamlError(err))
+	}
+	if inc.interpolate {
+		if err := interpolateYAML(&doc, frame.name); err != nil {
+			return err
+		}
+	}
+	if len(doc.Content) == 0 {
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: node.Line, Column: node.Column}
+	} else {
+		*node = *doc.Content[0]
+	}
+	files[node] = frame.name
+	inc.record(path, frame.name)
+	return inc.includeYAML(node, path, files)
+}
+
+// decodeIncludes decodes a JSON document into v, replacing {"$ref": ...}
+// objects with the values they reference and, if the includer interpolates,
+// expanding the references in string values. It returns the composed
+// document, for aliases.
+func (d *jsonDecoder) decodeIncludes(data []byte, v interface{}, strict bool, inc *includer) ([]byte, error) {
+	if !bytes.Contains(data, []byte(strconv.Quote(refKey))) && !inc.interpolate {
+		return data, decodeJSON(data, v, strict)
+	}
+
+	aliases := aliasKeys(reflect.TypeOf(v))
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		checker := &jsonChecker{data: data, decoder: json.NewDecoder(bytes.NewReader(data)), strict: strict, aliases: aliases, refs: inc}
+		if err := checker.check(rv.Elem().Type(), ""); err != nil {
+			return nil, jsonError(data, err)
+		}
+		if err := checker.errs.errorOrNil(); err != nil {
+			return nil, err
+		}
+	}
+
+	if inc.interpolate {
+		jsonReferenceLines(data, "", "", inc.lines)
+	}
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, jsonError(data, err)
+	}
+	tree, err := inc.resolveJSON(tree, "")
+	if err != nil {
+		return nil, err
+	}
+	if inc.interpolate {
+		if tree, err = inc.interpolateJSON(tree, reflect.TypeOf(v)); err != nil {
+			return nil, err
+		}
+	}
+	composed, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	// Errors were reported by the check, with their position; the composed
+	// document has none.
+	decoder = json.NewDecoder(bytes.NewReader(composed))
+	if strict && len(aliases) == 0 {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return nil, err
+	}
+	return composed, nil
+}
+
+// interpolateJSON expands the references in the strings of a composed JSON
+// document decoding into t. Errors name the file and line each value was
+// read from.
+func (inc *includer) interpolateJSON(tree interface{}, t reflect.Type) (interface{}, error) {
+	return interpolateTree(tree, t, JSONFormat, "", func(key string) (string, int) {
+		return inc.file(key), inc.lines[key]
+	})
+}
+
+// file returns the name of the file the value at a dotted document key was
+// read from: the file included closest above it, or the including file.
+func (inc *includer) file(key string) string {
+	for {
+		if name, ok := inc.keys[key]; ok {
+			return name
+		}
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			return inc.stack[0].name
+		}
+		key = key[:i]
+	}
+}
+
+// expandRef expands the variable references in a $ref at the dotted key path
+// if the includer interpolates.
+func (inc *includer) expandRef(ref, path string) (string, error) {
+	if !inc.interpolate {
+		return ref, nil
+	}
+	expanded, _, err := expandReferences(ref, inc.current())
+	if err != nil {
+		line := inc.lines[joinKey(path, refKey)]
+		return "", &DecodeError{File: inc.current(), Line: line, Message: prefixKey(path, err.Error())}
+	}
+	return expanded, nil
+}
+
+// refObject returns the reference of a {"$ref": "..."} object.
+func refObject(value interface{}) (string, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return "", false
+	}
+	ref, ok := object[refKey].(string)
+	return ref, ok
+}
+
+// resolveJSON replaces the $ref objects in a decoded JSON value with the
+// values they reference. path is the dotted key path of value.
+func (inc *includer) resolveJSON(value interface{}, path string) (interface{}, error) {
+	if ref, ok := refObject(value); ok {
+		ref, err := inc.expandRef(ref, path)
+		if err != nil {
+			return nil, err
+		}
+		frame, data, err := inc.open(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", prefixKey(path, refKey+" "+ref), err)
+		}
+		defer inc.close()
+
+		var target interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := seekJSONPointer(decoder, frame.pointer); err != nil {
+			return nil, fmt.Errorf("%s: %w", prefixKey(path, refKey+" "+ref), err)
+		}
+		if err := decoder.Decode(&target); err != nil {
+			return nil, withFile(frame.name, jsonError(data, err))
+		}
+		inc.record(path, frame.name)
+		if inc.interpolate {
+			jsonReferenceLines(data, frame.pointer, path, inc.lines)
+		}
+		return inc.resolveJSON(target, path)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			resolved, err := inc.resolveJSON(child, joinKey(path, key))
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+	case []interface{}:
+		for i, child := range v {
+			resolved, err := inc.resolveJSON(child, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+	}
+	return value, nil
+}
+
+// seekJSONPointer advances decoder to the value a JSON pointer (RFC 6901),
+// such as "/database/replicas/0", names. The empty pointer names the whole
+// document.
+func seekJSONPointer(decoder *json.Decoder, pointer string) error {
+	if pointer == "" {
+		return nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescape.Replace(token)
+		delim, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		found := false
+		switch delim {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				if key == token {
+					found = true
+					break
+				}
+				if err := skipJSONValue(decoder); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 {
+				break
+			}
+			for i := 0; i < index && decoder.More(); i++ {
+				if err := skipJSONValue(decoder); err != nil {
+					return err
+				}
+			}
+			found = decoder.More()
+		}
+		if !found {
+			return fmt.Errorf("JSON pointer %q not found", pointer)
+		}
+	}
+	return nil
+}
+
+// jsonReferenceLines records in lines the line of each string holding a
+// reference in the JSON value a pointer names in data, by dotted key path
+// below key. Syntax errors end the walk; the decoder reports them.
+func jsonReferenceLines(data []byte, pointer, key string, lines map[string]int) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if seekJSONPointer(decoder, pointer) == nil {
+		_ = jsonValueLines(decoder, data, key, lines)
+	}
+}
+
+// jsonValueLines records the lines of the strings holding a reference at or
+// below the next value of decoder, whose dotted key path is key.
+func jsonValueLines(decoder *json.Decoder, data []byte, key string, lines map[string]int) error {
+	offset := int(decoder.InputOffset())
+	for offset < len(data) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			name, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			if err := jsonValueLines(decoder, data, joinKey(key, name.(string)), lines); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			if err := jsonValueLines(decoder, data, fmt.Sprintf("%s[%d]", key, i), lines); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+	default:
+		if s, ok := token.(string); ok && strings.Contains(s, "${") {
+			lines[key], _ = position(data, offset)
+		}
+	}
+	return err
+}
+
+// skipJSONValue reads past the next value of decoder.
+func skipJSONValue(decoder *json.Decoder) error {
+	var skipped json.RawMessage
+	return decoder.Decode(&skipped)
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type includeConfig struct {
+	Name     string
+	Database struct {
+		Host string
+		Port int
+		TLS  struct {
+			Cert string
+		}
+	}
+	Upstreams []struct {
+		URL string
+	}
+}
+
+func TestDecodeFile_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml":      "name: app\ndatabase: !include db/database.yaml\nupstreams:\n  - !include upstream.yaml\n  - url: http://b\n",
+		"db/database.yaml": "<<: !include defaults.yaml\nhost: db.local\ntls: !include tls.yaml\n",
+		"db/defaults.yaml": "host: localhost\nport: 5432\n",
+		"db/tls.yaml":      "cert: /etc/db.pem\n",
+		"upstream.yaml":    "url: http://a\n",
+	})
+
+	var cfg includeConfig
+	if err := DecodeFile(filepath.Join(dir, "config.yaml"), &cfg); err != nil {
+		t.Fatalf("DecodeFile() error = %v", err)
+	}
+	if cfg.Name != "app" || cfg.Database.Host != "db.local" || cfg.Database.Port != 5432 || cfg.Database.TLS.Cert != "/etc/db.pem" {
+		t.Errorf("DecodeFile() = %+v", cfg)
+	}
+	if len(cfg.Upstreams) != 2 || cfg.Upstreams[0].URL != "http://a" || cfg.Upstreams[1].URL != "http://b" {
+		t.Errorf("Upstreams = %+v", cfg.Upstreams)
+	}
+}
+
+func TestDecodeFile_Ref(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json": `{
+  "name": "app",
+  "database": {"$ref": "shared/databases.json#/primary"},
+  "upstreams": [{"$ref": "#/defaults/upstream"}, {"url": "http://b"}],
+  "defaults": {"upstream": {"url": "http://a"}}
+}`,
+		"shared/databases.json": `{"primary": {"host": "db.local", "port": 5432, "tls": {"$ref": "tls.json"}}}`,
+		"shared/tls.json":       `{"cert": "/etc/db.pem"}`,
+	})
+
+	var cfg includeConfig
+	if err := DecodeFile(filepath.Join(dir, "config.json"), &cfg); err != nil {
+		t.Fatalf("DecodeFile() error = %v", err)
+	}
+	if cfg.Name != "app" || cfg.Database.Host != "db.local" || cfg.Database.Port != 5432 || cfg.Database.TLS.Cert != "/etc/db.pem" {
+		t.Errorf("DecodeFile() = %+v", cfg)
+	}
+	if len(cfg.Upstreams) != 2 || cfg.Upstreams[0].URL != "http://a" || cfg.Upstreams[1].URL != "http://b" {
+		t.Errorf("Upstreams = %+v", cfg.Upstreams)
+	}
+}
+
+func TestDecodeFile_IncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"type.yaml":      "database: !include db.yaml\n",
+		"db.yaml":        "host: db.local\nport: many\n",
+		"missing.yaml":   "name: app\ndatabase: !include nowhere.yaml\n",
+		"a.yaml":         "database: !include b.yaml\n",
+		"b.yaml":         "tls: !include a.yaml\n",
+		"unknown.json":   "{\"database\": {\"$ref\": \"db.json\"}}",
+		"db.json":        "{\n  \"host\": \"db.local\",\n  \"hots\": \"x\"\n}",
+		"pointer.json":   `{"database": {"$ref": "db.json#/primary"}}`,
+		"self.json":      `{"database": {"$ref": "#/database"}}`,
+		"extra-key.json": `{"database": {"$ref": "db.json", "port": 1}}`,
+	})
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"type.yaml", filepath.Join(dir, "db.yaml") + ":2:7: database.port: cannot unmarshal !!str `many` into int"},
+		{"missing.yaml", filepath.Join(dir, "missing.yaml") + ":2:11: database: !include nowhere.yaml: open " + filepath.Join(dir, "nowhere.yaml")},
+		{"a.yaml", filepath.Join(dir, "b.yaml") + ":1:6: database.tls: !include a.yaml: include cycle: " +
+			filepath.Join(dir, "a.yaml") + " -> " + filepath.Join(dir, "b.yaml") + " -> " + filepath.Join(dir, "a.yaml")},
+		{"unknown.json", filepath.Join(dir, "db.json") + `:3:3: unknown key "database.hots"`},
+		{"pointer.json", filepath.Join(dir, "pointer.json") + `:1:14: database: $ref db.json#/primary: JSON pointer "/primary" not found`},
+		{"self.json", filepath.Join(dir, "self.json") + `:1:14: database: $ref #/database: include cycle`},
+		{"extra-key.json", filepath.Join(dir, "extra-key.json") + `:1:14: database: $ref must be the only key and a string`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var cfg includeConfig
+			source := &FileSource{Path: filepath.Join(dir, tt.file), Strict: true}
+			err := source.Load(&cfg)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.want)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Errorf("Load() error type = %T, want *DecodeError", err)
+			}
+		})
+	}
+}
+
+func TestLoader_IncludeProvenance(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml":   "name: app\ndatabase: !include database.yaml\n",
+		"database.yaml": "host: db.local\ntls:\n  cert: /etc/db.pem\n",
+	})
+	path := filepath.Join(dir, "config.yaml")
+
+	var cfg includeConfig
+	l := NewLoader(WithFile(path))
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	provenance := l.(ProvenanceReporter).Provenance()
+	want := map[string]string{
+		"Name":              "file:" + path,
+		"Database.Host":     "file:" + filepath.Join(dir, "database.yaml"),
+		"Database.TLS.Cert": "file:" + filepath.Join(dir, "database.yaml"),
+	}
+	for field, origin := range want {
+		if got := provenance[field].String(); got != origin {
+			t.Errorf("origin of %s = %q, want %q", field, got, origin)
+		}
+	}
+
+	// Watch notices changes to included files
+	source := NewFileSource(path)
+	var before, after bytes.Buffer
+	if err := source.fingerprint(&before); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "database.yaml"), []byte("host: db2.local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.fingerprint(&after); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(before.Bytes(), after.Bytes()) {
+		t.Error("fingerprint did not change with the included file")
+	}
+}
+
+func TestFSSource_Include(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/config.yaml":   {Data: []byte("name: app\ndatabase: !include ../shared/db.yaml\n")},
+		"shared/db.yaml":     {Data: []byte("host: db.local\n")},
+		"conf/upstream.json": {Data: []byte(`{"url": "http://a"}`)},
+	}
+
+	var cfg includeConfig
+	source := NewFSSource(fsys, "conf/config.yaml")
+	report, err := source.loadReport(context.Background(), &cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Name != "app" || cfg.Database.Host != "db.local" {
+		t.Errorf("Load() = %+v", cfg)
+	}
+	if key := report.keys["Database"]; key != "shared/db.yaml" {
+		t.Errorf("key of Database = %q, want shared/db.yaml", key)
+	}
+}
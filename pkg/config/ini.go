@@ -0,0 +1,329 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// flatEntry is a key/value pair read from a flat configuration format.
+type flatEntry struct {
+	// key is the dotted path of the value, e.g. "database.max_conns".
+	key   string
+	value string
+	line  int
+}
+
+// iniDecoder decodes INI files.
+//
+// Sections name nested structs ([database], [database.pool]); keys outside
+// any section belong to the top-level struct. Lines starting with ";" or "#"
+// are comments, and values may be wrapped in single or double quotes.
+type iniDecoder struct{}
+
+// NewINIDecoder creates a new INI decoder.
+func NewINIDecoder() Decoder {
+	return &iniDecoder{}
+}
+
+// Decode decodes INI data from the reader into v, which must be a pointer
+// to a struct. Keys that match no field are ignored.
+func (d *iniDecoder) Decode(r io.Reader, v interface{}) error {
+	entries, err := parseINI(r)
+	if err != nil {
+		return err
+	}
+	return decodeFlat(entries, v, false)
+}
+
+// DecodeStrict decodes INI data from the reader into v, reporting all
+// keys that match no field.
+func (d *iniDecoder) DecodeStrict(r io.Reader, v interface{}) error {
+	entries, err := parseINI(r)
+	if err != nil {
+		return err
+	}
+	return decodeFlat(entries, v, true)
+}
+
+// propertiesDecoder decodes Java .properties files.
+//
+// Keys are dotted paths (database.max_conns=10) separated from values by
+// "=", ":" or whitespace. Lines starting with "#" or "!" are comments, a
+// trailing backslash continues the value on the next line, and the escapes
+// \t, \n, \r, \\ and \uXXXX are supported.
+type propertiesDecoder struct{}
+
+// NewPropertiesDecoder creates a new .properties decoder.
+func NewPropertiesDecoder() Decoder {
+	return &propertiesDecoder{}
+}
+
+// Decode decodes .properties data from the reader into v, which must be a pointer
+// to a struct. Keys that match no field are ignored.
+func (d *propertiesDecoder) Decode(r io.Reader, v interface{}) error {
+	entries, err := parseProperties(r)
+	if err != nil {
+		return err
+	}
+	return decodeFlat(entries, v, false)
+}
+
+// DecodeStrict decodes .properties data from the reader into v, reporting all
+// keys that match no field.
+func (d *propertiesDecoder) DecodeStrict(r io.Reader, v interface{}) error {
+	entries, err := parseProperties(r)
+	if err != nil {
+		return err
+	}
+	return decodeFlat(entries, v, true)
+}
+
+// parseINI reads the key/value pairs of an INI document, prefixing keys with
+// their section name.
+func parseINI(r io.Reader) ([]flatEntry, error) {
+	var entries []flatEntry
+	section := ""
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, &DecodeError{Line: lineNum, Message: "unterminated section header"}
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, &DecodeError{Line: lineNum, Message: "expected key = value"}
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, &DecodeError{Line: lineNum, Message: "empty key"}
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+
+		entries = append(entries, flatEntry{key: key, value: unquoteINIValue(strings.TrimSpace(value)), line: lineNum})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// unquoteINIValue removes matching single or double quotes around a value.
+func unquoteINIValue(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// parseProperties reads the key/value pairs of a .properties document.
+func parseProperties(r io.Reader) ([]flatEntry, error) {
+	var entries []flatEntry
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// Join continuation lines
+		start := lineNum
+		for endsWithContinuation(line) && scanner.Scan() {
+			lineNum++
+			line = line[:len(line)-1] + strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+
+		key, value := splitProperty(line)
+		key, err := unescapeProperty(key)
+		if err != nil {
+			return nil, &DecodeError{Line: start, Message: err.Error()}
+		}
+		value, err = unescapeProperty(value)
+		if err != nil {
+			return nil, &DecodeError{Line: start, Message: err.Error()}
+		}
+
+		entries = append(entries, flatEntry{key: key, value: value, line: start})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// endsWithContinuation reports whether a line ends with an odd number of
+// backslashes, i.e. continues on the next line.
+func endsWithContinuation(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// splitProperty splits a property line at the first unescaped "=", ":" or
+// whitespace, skipping whitespace around the separator.
+func splitProperty(line string) (key, value string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ""
+}
+
+// unescapeProperty resolves backslash escapes in a property key or value.
+// Unknown escapes yield the escaped character itself.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:i+5])
+			}
+			b.WriteRune(rune(code))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// decodeFlat stores flat key/value entries into the struct v points to,
+// converting values with the same rules as environment variables.
+//
+// Each segment of a dotted key matches a field by name, ignoring case,
+// underscores and dashes (max_conns and max-conns match MaxConns). Pointers
+// to nested structs are allocated as needed, and the remaining segments
+// below a map[string]T field form the map key. Keys that match no field are
+// ignored, or reported if strict unless they are the old name of a field
+// given by an alias= option. All errors are collected.
+func decodeFlat(entries []flatEntry, v interface{}, strict bool) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a non-nil pointer to a struct")
+	}
+
+	aliases := aliasKeys(rv.Type())
+	var errs DecodeErrors
+	for _, entry := range entries {
+		found, err := setFlatValue(rv.Elem(), strings.Split(entry.key, "."), entry.value)
+		switch {
+		case err != nil:
+			errs = append(errs, &DecodeError{Line: entry.line, Message: fmt.Sprintf("key %q: %v", entry.key, err)})
+		case !found && strict && !aliases[strings.ToLower(entry.key)]:
+			errs = append(errs, unknownKeyError(entry.line, 0, entry.key))
+		}
+	}
+	return errs.errorOrNil()
+}
+
+// setFlatValue follows the key segments from a struct to a field and sets
+// it. It reports whether the key matched a field.
+func setFlatValue(rv reflect.Value, segments []string, value string) (bool, error) {
+	field, ok := findFlatField(rv, segments[0])
+	if !ok {
+		return false, nil
+	}
+	rest := segments[1:]
+
+	if len(rest) == 0 {
+		return true, setFieldValue(field, value)
+	}
+
+	switch {
+	case isNestedStruct(field.Type()):
+		return setFlatValue(field, rest, value)
+	case field.Kind() == reflect.Ptr && isNestedStruct(field.Type().Elem()):
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setFlatValue(field.Elem(), rest, value)
+	case field.Kind() == reflect.Map && field.Type().Key().Kind() == reflect.String:
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		elem := reflect.New(field.Type().Elem()).Elem()
+		if err := setFieldValue(elem, value); err != nil {
+			return true, err
+		}
+		field.SetMapIndex(reflect.ValueOf(strings.Join(rest, ".")).Convert(field.Type().Key()), elem)
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// findFlatField finds the exported field of a struct matching a key segment.
+func findFlatField(rv reflect.Value, segment string) (reflect.Value, bool) {
+	want := normalizeFlatKey(segment)
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.IsExported() && normalizeFlatKey(field.Name) == want {
+			return rv.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// normalizeFlatKey lower-cases a name and removes underscores and dashes.
+func normalizeFlatKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flatPool struct {
+	MaxConns int
+	Timeout  time.Duration
+}
+
+type flatDatabase struct {
+	Host string
+	Port int
+	Pool *flatPool
+}
+
+type flatConfig struct {
+	Name     string
+	Debug    bool
+	Tags     []string
+	Labels   map[string]string
+	Database flatDatabase
+}
+
+func TestINIDecoder(t *testing.T) {
+	input := `; legacy service config
+name = "billing"
+debug = true
+tags = a,b,c
+
+[database]
+host = db.local
+port = 5432
+
+# pool settings
+[database.pool]
+max_conns = 20
+timeout = 5s
+
+[labels]
+team = payments
+cost.center = 42
+
+[unknown]
+ignored = yes
+`
+
+	var cfg flatConfig
+	if err := NewINIDecoder().Decode(strings.NewReader(input), &cfg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	expected := flatConfig{
+		Name:     "billing",
+		Debug:    true,
+		Tags:     []string{"a", "b", "c"},
+		Labels:   map[string]string{"team": "payments", "cost.center": "42"},
+		Database: flatDatabase{Host: "db.local", Port: 5432, Pool: &flatPool{MaxConns: 20, Timeout: 5 * time.Second}},
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Decode() = %+v, want %+v", cfg, expected)
+	}
+}
+
+func TestINIDecoder_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"missing equals", "name = a\nbroken\n", "line 2: expected key = value"},
+		{"unterminated section", "[database\n", "line 1: unterminated section header"},
+		{"empty key", " = value\n", "line 1: empty key"},
+		{"invalid value", "[database]\nport = abc\n", `line 2: key "database.port"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg flatConfig
+			err := NewINIDecoder().Decode(strings.NewReader(tt.input), &cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	var notStruct map[string]string
+	if err := NewINIDecoder().Decode(strings.NewReader("a = b"), &notStruct); err == nil {
+		t.Error("Decode() should reject targets that are not structs")
+	}
+}
+
+func TestPropertiesDecoder(t *testing.T) {
+	input := `# legacy service config
+! also a comment
+name=billing
+debug: true
+tags a;b;c
+database.host = db.local
+database.port=5432
+database.pool.max-conns = 20
+database.pool.timeout = \
+    5s
+labels.team = pay\u006dents\tteam
+labels.key\=with\:separators = x
+`
+
+	var cfg flatConfig
+	if err := NewPropertiesDecoder().Decode(strings.NewReader(input), &cfg); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	expected := flatConfig{
+		Name:     "billing",
+		Debug:    true,
+		Tags:     []string{"a", "b", "c"},
+		Labels:   map[string]string{"team": "payments\tteam", "key=with:separators": "x"},
+		Database: flatDatabase{Host: "db.local", Port: 5432, Pool: &flatPool{MaxConns: 20, Timeout: 5 * time.Second}},
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Decode() = %+v, want %+v", cfg, expected)
+	}
+}
+
+func TestPropertiesDecoder_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"invalid unicode escape", "name = \\uZZZZ\n", "line 1: invalid unicode escape"},
+		{"invalid value", "a=1\ndatabase.port = \\\n  abc\ndebug=true\n", `line 2: key "database.port"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg flatConfig
+			err := NewPropertiesDecoder().Decode(strings.NewReader(tt.input), &cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoader_Load_FlatFormats(t *testing.T) {
+	files := map[string]string{
+		"config.ini":        "[database]\nhost = ini.local\n",
+		"config.properties": "database.host=properties.local\n",
+		"config.toml":       "[database]\nhost = \"toml.local\"\n",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			var cfg flatConfig
+			if err := NewLoaderWithConfig(Config{FilePath: filePath}).Load(&cfg); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			want := strings.TrimPrefix(filepath.Ext(name), ".") + ".local"
+			if cfg.Database.Host != want {
+				t.Errorf("cfg.Database.Host = %q, want %q", cfg.Database.Host, want)
+			}
+		})
+	}
+}
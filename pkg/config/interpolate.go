@@ -0,0 +1,435 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// interpolateValue expands the variable references in a value read from the
+// file at path, starting on line:
+//
+//	${VAR}           value of environment variable VAR; an error if unset
+//	${VAR:-default}  value of VAR, or default if VAR is unset or empty
+//	${VAR:?message}  value of VAR; an error with message if VAR is unset or empty
+//	${file:path}     content of the file at path, without trailing newlines;
+//	                 relative paths are resolved against the directory of the
+//	                 configuration file
+//	$${              a literal "${"
+//
+// Defaults may contain references themselves (${A:-${B}}). Errors are
+// DecodeErrors with the file path and line of the reference.
+//
+// References are expanded in the values of a parsed document, never in its
+// text: comments are skipped and expanded values are not parsed as syntax.
+func interpolateValue(value, path string, line int) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	out, offset, err := expandReferences(value, path)
+	if err != nil {
+		return "", &DecodeError{File: path, Line: line + offset - 1, Message: err.Error()}
+	}
+	return out, nil
+}
+
+// interpolateYAML expands the references in the scalar values at or below a
+// YAML node read from the file at path. Keys are left alone. A plain scalar
+// that changes is typed again, so that "port: ${PORT}" decodes into an int;
+// quoted and explicitly tagged scalars keep their type.
+func interpolateYAML(node *yaml.Node, path string) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := interpolateYAML(child, path); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := interpolateYAML(node.Content[i], path); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		value, err := interpolateValue(node.Value, path, node.Line)
+		if err != nil {
+			return err
+		}
+		const typedStyles = yaml.TaggedStyle | yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle | yaml.LiteralStyle | yaml.FoldedStyle
+		if value != node.Value && node.Style&typedStyles == 0 {
+			node.Tag = ""
+		}
+		node.Value = value
+	}
+	return nil
+}
+
+// interpolateTree expands the references in the strings of a decoded JSON or
+// TOML value, in place. key is the dotted key path of value and locate
+// returns the file and line a key was read from, for errors. t is the type
+// value decodes into, or nil if unknown: expanded strings decoding into a
+// number or a boolean are converted, so that "port": "${PORT}" decodes into
+// an int.
+func interpolateTree(value interface{}, t reflect.Type, format Format, key string, locate func(key string) (string, int)) (interface{}, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			var childType reflect.Type
+			switch {
+			case t == nil:
+			case t.Kind() == reflect.Map:
+				childType = t.Elem()
+			case isNestedStruct(t):
+				if field, ok := documentField(t, name, format); ok {
+					childType = field.Type
+				}
+			}
+			expanded, err := interpolateTree(child, childType, format, joinKey(key, name), locate)
+			if err != nil {
+				return nil, err
+			}
+			v[name] = expanded
+		}
+	case []interface{}:
+		var elemType reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elemType = t.Elem()
+		}
+		for i, child := range v {
+			expanded, err := interpolateTree(child, elemType, format, fmt.Sprintf("%s[%d]", key, i), locate)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	case string:
+		if !strings.Contains(v, "${") {
+			return v, nil
+		}
+		path, line := locate(key)
+		expanded, _, err := expandReferences(v, path)
+		if err != nil {
+			return nil, &DecodeError{File: path, Line: line, Message: prefixKey(key, err.Error())}
+		}
+		return convertExpanded(expanded, t), nil
+	}
+	return value, nil
+}
+
+// convertExpanded converts an expanded string to the number or boolean held
+// by t, if it parses as one. Types with their own text form are left strings.
+func convertExpanded(s string, t reflect.Type) interface{} {
+	if t == nil || isTextUnmarshaler(t) {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+// interpolateData expands the references in the values of a TOML, INI or
+// .properties document read from the file at path, returning the document
+// to decode. YAML and JSON documents are expanded by their decoders. Syntax
+// errors are left to the decoder.
+func interpolateData(format Format, data []byte, path string, t reflect.Type) ([]byte, error) {
+	switch format {
+	case TOMLFormat:
+		var tree map[string]interface{}
+		if toml.Unmarshal(data, &tree) != nil {
+			return data, nil
+		}
+		// The marshaled document has new positions: errors take the line
+		// of the value from the original.
+		lines := tomlReferenceLines(data)
+		locate := func(key string) (string, int) { return path, lines[key] }
+		if _, err := interpolateTree(tree, t, format, "", locate); err != nil {
+			return nil, err
+		}
+		return toml.Marshal(tree)
+	case INIFormat, PropertiesFormat:
+		return interpolateFlat(format, data, path)
+	}
+	return data, nil
+}
+
+// tomlReferenceLines returns the line of each string holding a reference in
+// a TOML document, by dotted key path as interpolateTree walks it.
+func tomlReferenceLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	// arrays holds the index of the last table of each array of tables.
+	arrays := make(map[string]int)
+	table := ""
+
+	var p unstable.Parser
+	p.Reset(data)
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			parts := tomlKeyParts(expr.Key())
+			if expr.Kind == unstable.ArrayTable {
+				name := strings.Join(parts, ".")
+				index, ok := arrays[name]
+				if ok {
+					index++
+				}
+				// A new table restarts the arrays of tables nested in it.
+				for nested := range arrays {
+					if strings.HasPrefix(nested, name+".") {
+						delete(arrays, nested)
+					}
+				}
+				arrays[name] = index
+			}
+
+			plain := ""
+			table = ""
+			for _, part := range parts {
+				plain, table = joinKey(plain, part), joinKey(table, part)
+				if index, ok := arrays[plain]; ok {
+					table += fmt.Sprintf("[%d]", index)
+				}
+			}
+		case unstable.KeyValue:
+			key := joinKey(table, strings.Join(tomlKeyParts(expr.Key()), "."))
+			tomlValueLines(&p, expr.Value(), key, lines)
+		}
+	}
+	return lines
+}
+
+// tomlValueLines records in lines the line of each string holding a
+// reference at or below a TOML value node at the dotted key path.
+func tomlValueLines(p *unstable.Parser, node *unstable.Node, key string, lines map[string]int) {
+	switch node.Kind {
+	case unstable.String:
+		if bytes.Contains(node.Data, []byte("${")) {
+			lines[key] = p.Shape(node.Raw).Start.Line
+		}
+	case unstable.Array:
+		children := node.Children()
+		for i := 0; children.Next(); i++ {
+			tomlValueLines(p, children.Node(), fmt.Sprintf("%s[%d]", key, i), lines)
+		}
+	case unstable.InlineTable:
+		children := node.Children()
+		for children.Next() {
+			child := children.Node()
+			tomlValueLines(p, child.Value(), joinKey(key, strings.Join(tomlKeyParts(child.Key()), ".")), lines)
+		}
+	}
+}
+
+// tomlKeyParts returns the parts of a dotted TOML key.
+func tomlKeyParts(key unstable.Iterator) []string {
+	var parts []string
+	for key.Next() {
+		parts = append(parts, string(key.Node().Data))
+	}
+	return parts
+}
+
+// interpolateFlat expands the references in the values of an INI or
+// .properties document and writes its entries back, each on its original
+// line so that decode errors keep their position. Section names become key
+// prefixes.
+func interpolateFlat(format Format, data []byte, path string) ([]byte, error) {
+	parse := parseINI
+	if format == PropertiesFormat {
+		parse = parseProperties
+	}
+	entries, err := parse(bytes.NewReader(data))
+	if err != nil {
+		return data, nil
+	}
+
+	var b strings.Builder
+	line := 1
+	for _, entry := range entries {
+		value, err := interpolateValue(entry.value, path, entry.line)
+		if err != nil {
+			return nil, err
+		}
+		for ; line < entry.line; line++ {
+			b.WriteByte('\n')
+		}
+		if format == PropertiesFormat {
+			b.WriteString(escapeProperty(entry.key, true) + "=" + escapeProperty(value, false))
+			continue
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, &DecodeError{File: path, Line: entry.line, Message: prefixKey(entry.key, "interpolated value contains a line break")}
+		}
+		b.WriteString(entry.key + " = \"" + value + "\"")
+	}
+	return []byte(b.String()), nil
+}
+
+// escapeProperty escapes a .properties key or value for parseProperties.
+func escapeProperty(s string, key bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == '\f':
+			b.WriteString(`\f`)
+		case c == ' ' && (key || i == 0), key && (c == '=' || c == ':' || c == '#' || c == '!'):
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// expandReferences expands every reference in content. On failure it returns
+// the line of content holding the offending reference.
+func expandReferences(content, path string) (string, int, error) {
+	var out strings.Builder
+	line := 1
+
+	for i := 0; i < len(content); {
+		switch {
+		case strings.HasPrefix(content[i:], "$${"):
+			out.WriteString("${")
+			i += 3
+		case strings.HasPrefix(content[i:], "${"):
+			end := matchingBrace(content, i+2)
+			if end < 0 {
+				return "", line, fmt.Errorf("unterminated variable reference")
+			}
+
+			value, err := expandReference(content[i+2:end], path)
+			if err != nil {
+				return "", line, err
+			}
+			out.WriteString(value)
+			line += strings.Count(content[i:end], "\n")
+			i = end + 1
+		default:
+			if content[i] == '\n' {
+				line++
+			}
+			out.WriteByte(content[i])
+			i++
+		}
+	}
+
+	return out.String(), line, nil
+}
+
+// matchingBrace returns the index of the "}" closing a reference whose body
+// starts at start, allowing nested "${...}" references, or -1 if there is none.
+func matchingBrace(content string, start int) int {
+	depth := 1
+	for i := start; i < len(content); i++ {
+		switch {
+		case strings.HasPrefix(content[i:], "${"):
+			depth++
+			i++
+		case content[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expandReference resolves the body of a "${...}" reference.
+func expandReference(expr, path string) (string, error) {
+	if filePath, ok := strings.CutPrefix(expr, "file:"); ok {
+		return readReferencedFile(filePath, path)
+	}
+
+	name, op, operand := expr, "", ""
+	if idx := strings.IndexByte(expr, ':'); idx >= 0 {
+		name, op = expr[:idx], expr[idx:]
+		if !strings.HasPrefix(op, ":-") && !strings.HasPrefix(op, ":?") {
+			return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+		}
+		op, operand = op[:2], op[2:]
+	}
+	if name == "" {
+		return "", fmt.Errorf("empty variable name in ${%s}", expr)
+	}
+
+	value, set := os.LookupEnv(name)
+	switch op {
+	case ":-":
+		if value != "" {
+			return value, nil
+		}
+		// The default may itself contain references
+		expanded, _, err := expandReferences(operand, path)
+		return expanded, err
+	case ":?":
+		if value != "" {
+			return value, nil
+		}
+		if operand == "" {
+			operand = "is required"
+		}
+		return "", fmt.Errorf("variable %s: %s", name, operand)
+	}
+
+	if !set {
+		return "", fmt.Errorf("variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// readReferencedFile reads a file referenced by ${file:path}, relative to the
+// directory of the configuration file.
+func readReferencedFile(filePath, configPath string) (string, error) {
+	if filePath == "" {
+		return "", fmt.Errorf("empty path in ${file:}")
+	}
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(filepath.Dir(configPath), filePath)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("read referenced file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(filepath.Join(tmpDir, "db_password"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatalf("Failed to create secret file: %v", err)
+	}
+
+	os.Setenv("INTERP_USER", "admin")
+	os.Setenv("INTERP_EMPTY", "")
+	defer os.Unsetenv("INTERP_USER")
+	defer os.Unsetenv("INTERP_EMPTY")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "no references",
+			content: "port: 8080\nprice: $5\n",
+			want:    "port: 8080\nprice: $5\n",
+		},
+		{
+			name:    "variable",
+			content: "dsn: postgres://${INTERP_USER}@db/app",
+			want:    "dsn: postgres://admin@db/app",
+		},
+		{
+			name:    "empty variable is allowed",
+			content: "name: '${INTERP_EMPTY}'",
+			want:    "name: ''",
+		},
+		{
+			name:    "default for unset variable",
+			content: "pass: ${INTERP_MISSING:-dev}",
+			want:    "pass: dev",
+		},
+		{
+			name:    "default for empty variable",
+			content: "pass: ${INTERP_EMPTY:-dev}",
+			want:    "pass: dev",
+		},
+		{
+			name:    "default not used when set",
+			content: "user: ${INTERP_USER:-nobody}",
+			want:    "user: admin",
+		},
+		{
+			name:    "empty default",
+			content: "user: '${INTERP_MISSING:-}'",
+			want:    "user: ''",
+		},
+		{
+			name:    "nested default",
+			content: "user: ${INTERP_MISSING:-${INTERP_USER}}",
+			want:    "user: admin",
+		},
+		{
+			name:    "required with message",
+			content: "user: ${INTERP_USER:?set INTERP_USER}",
+			want:    "user: admin",
+		},
+		{
+			name:    "file reference",
+			content: "password: ${file:db_password}",
+			want:    "password: s3cret",
+		},
+		{
+			name:    "escaped reference",
+			content: "template: $${HOME}",
+			want:    "template: ${HOME}",
+		},
+		{
+			name:    "unset variable",
+			content: "a: 1\nb: ${INTERP_MISSING}\n",
+			wantErr: configPath + ":2: variable INTERP_MISSING is not set",
+		},
+		{
+			name:    "required variable with message",
+			content: "a: 1\n\n\nb: ${INTERP_EMPTY:?database password is required}\n",
+			wantErr: configPath + ":4: variable INTERP_EMPTY: database password is required",
+		},
+		{
+			name:    "required variable without message",
+			content: "b: ${INTERP_MISSING:?}",
+			wantErr: configPath + ":1: variable INTERP_MISSING: is required",
+		},
+		{
+			name:    "missing file",
+			content: "a: 1\nb: ${file:missing}",
+			wantErr: configPath + ":2: read referenced file",
+		},
+		{
+			name:    "unterminated reference",
+			content: "a: ${INTERP_USER",
+			wantErr: configPath + ":1: unterminated variable reference",
+		},
+		{
+			name:    "invalid operator",
+			content: "a: ${INTERP_USER:+x}",
+			wantErr: configPath + ":1: invalid variable reference",
+		},
+		{
+			name:    "line counted after multi-line default",
+			content: "a: ${INTERP_USER:-x\ny}\nb: ${INTERP_MISSING}",
+			wantErr: configPath + ":3: variable INTERP_MISSING is not set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interpolateValue(tt.content, configPath, 1)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("interpolateValue() error = %v, want prefix %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpolateValue() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("interpolateValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoader_Load_Interpolate(t *testing.T) {
+	type InterpConfig struct {
+		DSN  string
+		Port int
+	}
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.yaml")
+	content := "dsn: \"postgres://${INTERP_DB_USER}:${INTERP_DB_PASS:-dev}@db/app\"\nport: ${INTERP_PORT:-8080}\n"
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	os.Setenv("INTERP_DB_USER", "app")
+	defer os.Unsetenv("INTERP_DB_USER")
+
+	var cfg InterpConfig
+	loader := NewLoaderWithConfig(Config{FilePath: filePath, Interpolate: true})
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.DSN != "postgres://app:dev@db/app" {
+		t.Errorf("cfg.DSN = %q, want %q", cfg.DSN, "postgres://app:dev@db/app")
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("cfg.Port = %d, want %d", cfg.Port, 8080)
+	}
+
+	// Interpolation is opt-in
+	var raw InterpConfig
+	content = "dsn: \"${INTERP_DB_USER}\"\n"
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := NewLoaderWithConfig(Config{FilePath: filePath}).Load(&raw); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if raw.DSN != "${INTERP_DB_USER}" {
+		t.Errorf("raw.DSN = %q, want the reference left as-is", raw.DSN)
+	}
+}
+
+func TestLoader_Load_InterpolateParsedValues(t *testing.T) {
+	type Database struct {
+		Host string
+		Port int
+	}
+	type InterpConfig struct {
+		Password string
+		Note     string
+		Port     int
+		Debug    bool
+		Database Database
+	}
+
+	t.Setenv("INTERP_PASSWORD", "ab #cd")
+	t.Setenv("INTERP_NOTE", "x\"\nport: 1\nquote: '")
+	t.Setenv("INTERP_PORT", "9090")
+	t.Setenv("INTERP_DEBUG", "true")
+	t.Setenv("INTERP_HOST", "db.local")
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: "# set ${INTERP_UNSET} in production\n" +
+				"password: ${INTERP_PASSWORD}\n" +
+				"note: \"${INTERP_NOTE}\"\n" +
+				"port: ${INTERP_PORT} # ${INTERP_UNSET}\n" +
+				"debug: ${INTERP_DEBUG}\n" +
+				"database:\n  host: ${INTERP_HOST}\n  port: 5432\n",
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{"password": "${INTERP_PASSWORD}", "note": "${INTERP_NOTE}", "port": "${INTERP_PORT}",` +
+				` "debug": "${INTERP_DEBUG}", "database": {"host": "${INTERP_HOST}", "port": 5432}}`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: "# ${INTERP_UNSET}\npassword = \"${INTERP_PASSWORD}\"\nnote = \"${INTERP_NOTE}\"\n" +
+				"port = \"${INTERP_PORT}\"\ndebug = \"${INTERP_DEBUG}\"\n[database]\nhost = \"${INTERP_HOST}\"\nport = 5432\n",
+		},
+		{
+			name: "properties",
+			file: "config.properties",
+			content: "# ${INTERP_UNSET}\npassword=${INTERP_PASSWORD}\nnote=${INTERP_NOTE}\nport=${INTERP_PORT}\n" +
+				"debug=${INTERP_DEBUG}\ndatabase.host=${INTERP_HOST}\ndatabase.port=5432\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			var cfg InterpConfig
+			loader := NewLoaderWithConfig(Config{FilePath: filePath, Interpolate: true})
+			if err := loader.Load(&cfg); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			// Values are substituted after parsing, so they are never
+			// read as syntax and references in comments are ignored.
+			expected := InterpConfig{
+				Password: "ab #cd",
+				Note:     "x\"\nport: 1\nquote: '",
+				Port:     9090,
+				Debug:    true,
+				Database: Database{Host: "db.local", Port: 5432},
+			}
+			if cfg != expected {
+				t.Errorf("Load() = %+v, want %+v", cfg, expected)
+			}
+		})
+	}
+}
+
+func TestLoader_Load_InterpolateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		// included is the content of db.json, next to the file.
+		included string
+		wantErr  string
+	}{
+		{"yaml line", "config.yaml", "# comment\nname: ok\nnote: ${INTERP_UNSET}\n", "", "config.yaml:3: variable INTERP_UNSET is not set"},
+		{"json line", "config.json", "{\n  \"name\": \"ok\",\n  \"database\": {\"host\": \"${INTERP_UNSET}\"}\n}", "", "config.json:3: database.host: variable INTERP_UNSET is not set"},
+		{"toml line", "config.toml", "name = \"ok\"\n\n[[servers]]\nhost = \"a\"\n\n[[servers]]\nhost = \"${INTERP_UNSET}\"\n", "", "config.toml:7: servers[1].host: variable INTERP_UNSET is not set"},
+		{"ini line", "config.ini", "[database]\n\nhost = ${INTERP_UNSET}\n", "", "config.ini:3: variable INTERP_UNSET is not set"},
+		{"json ref line", "config.json", `{"database": {"$ref": "db.json"}}`, "{\n  \"host\": \"${INTERP_UNSET}\"\n}", "db.json:2: database.host: variable INTERP_UNSET is not set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filePath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			if tt.included != "" {
+				if err := os.WriteFile(filepath.Join(filepath.Dir(filePath), "db.json"), []byte(tt.included), 0644); err != nil {
+					t.Fatalf("Failed to create included file: %v", err)
+				}
+			}
+
+			var cfg struct {
+				Name     string
+				Note     string
+				Database struct{ Host string }
+				Servers  []struct{ Host string }
+			}
+			err := NewLoaderWithConfig(Config{FilePath: filePath, Interpolate: true}).Load(&cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SliceMergeStrategy controls how a slice in a configuration file combines
+// with the same slice from an earlier file.
+type SliceMergeStrategy int
+
+const (
+	// SliceReplace makes the later file's slice replace the earlier one.
+	SliceReplace SliceMergeStrategy = iota
+	// SliceAppend appends the later file's slice to the earlier one.
+	// Slices set by defaults or the environment are always replaced.
+	SliceAppend
+)
+
+// configFiles returns the configuration files to load, in order: FilePath,
+// then every entry of FilePaths. Directories expand to the files inside them
+// with a known format, and glob patterns to their matches, both sorted by
+// name. Each listed file is followed by its profile overlay, if it exists.
+func (l *loader) configFiles() ([]string, error) {
+	var entries []string
+	if l.config.FilePath != "" {
+		entries = append(entries, l.config.FilePath)
+	}
+	entries = append(entries, l.config.FilePaths...)
+
+	profile := l.profile()
+	var files []string
+	for _, entry := range entries {
+		expanded, err := expandFileEntry(entry, profile)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, expanded...)
+	}
+	return files, nil
+}
+
+// profile returns the configured profile, falling back to the ProfileEnv variable.
+func (l *loader) profile() string {
+	if l.config.Profile != "" {
+		return l.config.Profile
+	}
+	if l.config.ProfileEnv != "" {
+		return os.Getenv(l.config.ProfileEnv)
+	}
+	return ""
+}
+
+// expandFileEntry expands one FilePaths entry into the files it denotes.
+// A glob pattern matching nothing yields no files; a missing plain file is
+// kept, so that loading it reports the error.
+func expandFileEntry(entry, profile string) ([]string, error) {
+	if strings.ContainsAny(entry, "*?[") {
+		matches, err := filepath.Glob(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file pattern %q: %w", entry, err)
+		}
+		return regularFiles(matches), nil
+	}
+
+	info, err := os.Stat(entry)
+	if err == nil && info.IsDir() {
+		return directoryFiles(entry)
+	}
+
+	files := []string{entry}
+	if overlay := overlayPath(entry, profile); overlay != "" {
+		if info, err := os.Stat(overlay); err == nil && !info.IsDir() {
+			files = append(files, overlay)
+		}
+	}
+	return files, nil
+}
+
+// directoryFiles returns the files with a known format in dir, sorted by
+// name. Hidden files and subdirectories are skipped.
+func directoryFiles(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read config directory: %w", err)
+	}
+
+	var files []string
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || strings.HasPrefix(name, ".") || DetectFormat(name) == UnknownFormat {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// regularFiles filters paths down to existing regular files, keeping their order.
+func regularFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// overlayPath returns the profile overlay of a file (config.yaml with
+// profile "production" becomes config.production.yaml), or "" without a profile.
+func overlayPath(path, profile string) string {
+	if profile == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// detachSlices removes the slices last set by a configuration file from cfg,
+// returning them by field path, so that the next file decodes into nil
+// slices. Call attachSlices afterwards to combine old and new values.
+func detachSlices(cfg interface{}, origins Provenance) map[string]reflect.Value {
+	detached := make(map[string]reflect.Value)
+	forEachLeaf(cfg, func(path string, v reflect.Value) {
+		if v.Kind() != reflect.Slice || v.IsNil() || origins[path].Source != "file" {
+			return
+		}
+		detached[path] = reflect.ValueOf(v.Interface())
+		v.Set(reflect.Zero(v.Type()))
+	})
+	return detached
+}
+
+// attachSlices restores detached slices, appending the values decoded in
+// between. Slices the file did not set get their previous value back.
+func attachSlices(cfg interface{}, detached map[string]reflect.Value) {
+	if len(detached) == 0 {
+		return
+	}
+	forEachLeaf(cfg, func(path string, v reflect.Value) {
+		previous, ok := detached[path]
+		if !ok {
+			return
+		}
+		if v.IsNil() {
+			v.Set(previous)
+			return
+		}
+		merged := reflect.MakeSlice(v.Type(), 0, previous.Len()+v.Len())
+		merged = reflect.AppendSlice(merged, previous)
+		v.Set(reflect.AppendSlice(merged, v))
+	})
+}
+
+// forEachLeaf calls fn with the dotted path and settable value of every leaf
+// field of the struct cfg points to, descending into nested structs and
+// non-nil pointers to nested structs.
+func forEachLeaf(cfg interface{}, fn func(path string, v reflect.Value)) {
+	rv := reflect.ValueOf(cfg)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct && rv.CanAddr() {
+		forEachStructLeaf(rv, "", fn)
+	}
+}
+
+// forEachStructLeaf walks the fields of a struct value for forEachLeaf.
+func forEachStructLeaf(rv reflect.Value, path string, fn func(path string, v reflect.Value)) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+		switch {
+		case isNestedStruct(field.Type):
+			forEachStructLeaf(fieldValue, fieldPath, fn)
+		case field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem()) && !fieldValue.IsNil():
+			forEachStructLeaf(fieldValue.Elem(), fieldPath, fn)
+		default:
+			fn(fieldPath, fieldValue)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type layeredConfig struct {
+	Name     string
+	Port     int
+	Hosts    []string `config:"default=localhost"`
+	Labels   map[string]string
+	Database struct {
+		Host string
+		User string
+	}
+}
+
+// writeFiles creates files relative to dir, creating parent directories.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+}
+
+func TestLoader_Load_LayeredFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"config.yaml":             "name: app\nport: 8080\nhosts: [a, b]\nlabels:\n  team: core\n  tier: web\ndatabase:\n  host: db.local\n  user: app\n",
+		"config.production.yaml":  "port: 9090\nlabels:\n  tier: api\n",
+		"conf.d/10-hosts.yaml":    "hosts: [c]\n",
+		"conf.d/20-database.json": `{"database": {"host": "db.prod"}}`,
+		"conf.d/.hidden.yaml":     "name: hidden\n",
+		"conf.d/README.txt":       "not a config file",
+		"conf.d/nested/skip.yaml": "name: nested\n",
+		"extra/override.toml":     "name = \"billing\"\n",
+	})
+
+	os.Setenv("LAYERED_ENV", "production")
+	defer os.Unsetenv("LAYERED_ENV")
+
+	loader := NewLoaderWithConfig(Config{
+		FilePath:   filepath.Join(tmpDir, "config.yaml"),
+		FilePaths:  []string{filepath.Join(tmpDir, "conf.d"), filepath.Join(tmpDir, "extra", "*.toml"), filepath.Join(tmpDir, "none", "*.yaml")},
+		ProfileEnv: "LAYERED_ENV",
+	})
+
+	var cfg layeredConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Name != "billing" {
+		t.Errorf("cfg.Name = %q, want %q", cfg.Name, "billing")
+	}
+	if cfg.Port != 9090 {
+		t.Errorf("cfg.Port = %d, want %d (profile overlay)", cfg.Port, 9090)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []string{"c"}) {
+		t.Errorf("cfg.Hosts = %v, want [c] (slices replaced)", cfg.Hosts)
+	}
+	if !reflect.DeepEqual(cfg.Labels, map[string]string{"team": "core", "tier": "api"}) {
+		t.Errorf("cfg.Labels = %v, want merged map", cfg.Labels)
+	}
+	if cfg.Database.Host != "db.prod" || cfg.Database.User != "app" {
+		t.Errorf("cfg.Database = %+v, want host overridden and user kept", cfg.Database)
+	}
+
+	prov := loader.(ProvenanceReporter).Provenance()
+	expected := map[string]string{
+		"Name":          "file:" + filepath.Join(tmpDir, "extra", "override.toml"),
+		"Port":          "file:" + filepath.Join(tmpDir, "config.production.yaml"),
+		"Hosts":         "file:" + filepath.Join(tmpDir, "conf.d", "10-hosts.yaml"),
+		"Database.Host": "file:" + filepath.Join(tmpDir, "conf.d", "20-database.json"),
+		"Database.User": "file:" + filepath.Join(tmpDir, "config.yaml"),
+	}
+	for path, want := range expected {
+		if got := prov[path].String(); got != want {
+			t.Errorf("Provenance()[%s] = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestLoader_Load_SliceAppend(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"base.yaml":     "hosts: [a, b]\n",
+		"overlay.yaml":  "hosts: [c]\n",
+		"unrelated.yml": "port: 1\n",
+	})
+
+	loader := NewLoaderWithConfig(Config{
+		FilePaths: []string{
+			filepath.Join(tmpDir, "base.yaml"),
+			filepath.Join(tmpDir, "overlay.yaml"),
+			filepath.Join(tmpDir, "unrelated.yml"),
+		},
+		SliceMerge: SliceAppend,
+	})
+
+	var cfg layeredConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// The default is replaced by the first file; later files append.
+	if !reflect.DeepEqual(cfg.Hosts, []string{"a", "b", "c"}) {
+		t.Errorf("cfg.Hosts = %v, want [a b c]", cfg.Hosts)
+	}
+	if got := loader.(ProvenanceReporter).Provenance()["Hosts"].Key; got != filepath.Join(tmpDir, "overlay.yaml") {
+		t.Errorf("Provenance()[Hosts].Key = %q, want overlay file", got)
+	}
+}
+
+func TestLoader_Load_SliceAppend_EqualToDefault(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"base.yaml":    "hosts: [localhost]\n",
+		"overlay.yaml": "hosts: [c]\n",
+	})
+
+	loader := NewLoaderWithConfig(Config{
+		FilePaths:  []string{filepath.Join(tmpDir, "base.yaml"), filepath.Join(tmpDir, "overlay.yaml")},
+		SliceMerge: SliceAppend,
+	})
+
+	var cfg layeredConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// The first file sets the slice although it equals the default
+	if !reflect.DeepEqual(cfg.Hosts, []string{"localhost", "c"}) {
+		t.Errorf("cfg.Hosts = %v, want [localhost c]", cfg.Hosts)
+	}
+}
+
+func TestLoader_Load_LayeredFiles_Missing(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{"config.yaml": "name: app\n"})
+
+	// A missing profile overlay is fine
+	loader := NewLoaderWithConfig(Config{
+		FilePath: filepath.Join(tmpDir, "config.yaml"),
+		Profile:  "staging",
+	})
+	var cfg layeredConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v, want missing overlay to be ignored", err)
+	}
+
+	// A missing listed file is not
+	loader = NewLoaderWithConfig(Config{
+		FilePaths: []string{filepath.Join(tmpDir, "config.yaml"), filepath.Join(tmpDir, "missing.yaml")},
+	})
+	err := loader.Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Errorf("Load() error = %v, want error naming missing.yaml", err)
+	}
+
+	// Nor is an invalid pattern
+	loader = NewLoaderWithConfig(Config{FilePaths: []string{filepath.Join(tmpDir, "[")}})
+	if err := loader.Load(&cfg); err == nil {
+		t.Error("Load() should fail for an invalid glob pattern")
+	}
+}
+
+func TestLoader_SourcesFingerprint(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{"conf.d/a.yaml": "name: a\n"})
+
+	l := NewLoaderWithConfig(Config{FilePaths: []string{filepath.Join(tmpDir, "conf.d")}}).(*loader)
+	before, err := l.sourcesFingerprint()
+	if err != nil {
+		t.Fatalf("sourcesFingerprint() error = %v", err)
+	}
+
+	writeFiles(t, tmpDir, map[string]string{"conf.d/b.yaml": ""})
+	after, err := l.sourcesFingerprint()
+	if err != nil {
+		t.Fatalf("sourcesFingerprint() error = %v", err)
+	}
+	if before == after {
+		t.Error("sourcesFingerprint() should change when a file is added to a directory")
+	}
+}
@@ -1,10 +1,22 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
 )
 
 // Loader defines the interface for loading configuration.
+//
+// The loaders returned by NewLoader and NewLoaderWithConfig also implement
+// Watcher, ValidationRegistrar and ProvenanceReporter, reached with a type
+// assertion:
+//
+//	loader := config.NewLoader(config.WithFile("config.yaml"))
+//	err := loader.(config.Watcher).Watch(ctx, &cfg, onChange)
 type Loader interface {
 	Load(cfg interface{}) error
 	LoadFromFile(path string, cfg interface{}) error
@@ -12,60 +24,201 @@ type Loader interface {
 	SetDefaults(cfg interface{}) error
 }
 
+// Watcher is implemented by loaders that reload configuration when their
+// sources change.
+type Watcher interface {
+	Watch(ctx context.Context, cfg interface{}, onChange func(cfg interface{})) error
+}
+
+// ValidationRegistrar is implemented by loaders with their own validation
+// rules.
+type ValidationRegistrar interface {
+	RegisterValidation(name string, fn ValidationFunc)
+}
+
+// ProvenanceReporter is implemented by loaders that record the origin of
+// each loaded value.
+type ProvenanceReporter interface {
+	Provenance() Provenance
+}
+
+// The loader implements every optional loader interface.
+var (
+	_ Watcher             = (*loader)(nil)
+	_ ValidationRegistrar = (*loader)(nil)
+	_ ProvenanceReporter  = (*loader)(nil)
+)
+
 // Config holds configuration for the loader.
 type Config struct {
 	// FilePath is the path to the configuration file (optional).
 	FilePath string
+	// FilePaths lists further configuration files, directories and glob
+	// patterns, loaded in order after FilePath (optional). Later files
+	// override earlier ones field by field and merge into maps.
+	FilePaths []string
+	// Profile selects overlay files: after each listed file such as
+	// config.yaml, config.<profile>.yaml is loaded if it exists (optional).
+	Profile string
+	// ProfileEnv names an environment variable holding the profile, such as
+	// APP_ENV, used when Profile is empty (optional).
+	ProfileEnv string
+	// SliceMerge controls how slices from later files combine with slices
+	// from earlier files (default: SliceReplace).
+	SliceMerge SliceMergeStrategy
+	// Interpolate expands ${VAR}, ${VAR:-default}, ${VAR:?message} and
+	// ${file:path} references in the values of configuration files once they
+	// are parsed (optional).
+	Interpolate bool
+	// Strict makes keys in configuration files that match no field, such as a
+	// misspelled "timout", an error (optional). Every unknown key is
+	// reported as file:line:column.
+	Strict bool
 	// EnvPrefix is the prefix for environment variables (optional).
 	EnvPrefix string
+	// EnvSeparator joins the prefix and nested field names in environment
+	// variable keys (default: "_").
+	EnvSeparator string
+	// EnvFlatNames keeps the legacy environment naming, where nested fields
+	// are not prefixed with their parent's name (optional).
+	EnvFlatNames bool
+	// Args are command-line arguments (typically os.Args[1:]) parsed by a
+	// FlagSource after the environment, giving flags the highest priority
+	// (optional). Flags are not parsed when Args is nil.
+	Args []string
 	// ValidateAfterLoad enables validation after loading (default: true).
 	ValidateAfterLoad bool
+	// WatchInterval is how often Watch polls FilePath for changes (default: 2s).
+	WatchInterval time.Duration
+	// OnWatchError is called when Watch fails to reload the configuration (optional).
+	// The previously loaded configuration stays in effect.
+	OnWatchError func(err error)
+	// OnWarning is called for each deprecated key a load reads: an old name
+	// given with the alias= tag option, or a field tagged deprecated=
+	// (optional). Warnings are dropped when it is nil.
+	OnWarning func(w Warning)
 }
 
 // loader is the concrete implementation of Loader.
 type loader struct {
 	config Config
+	rules  *ruleRegistry
+
+	// sources replaces the default pipeline when set (see WithSources).
+	sources []sourceEntry
+
+	mu         sync.Mutex
+	provenance Provenance
 }
 
 // NewLoader creates a new loader with default configuration.
-func NewLoader() Loader {
+func NewLoader(opts ...Option) Loader {
 	return NewLoaderWithConfig(Config{
 		ValidateAfterLoad: true,
-	})
+	}, opts...)
 }
 
 // NewLoaderWithConfig creates a new loader with the given configuration.
-func NewLoaderWithConfig(cfg Config) Loader {
-	return &loader{
+func NewLoaderWithConfig(cfg Config, opts ...Option) Loader {
+	l := &loader{
 		config: cfg,
+		rules:  newRuleRegistry(),
+	}
+	for _, opt := range opts {
+		opt(l)
 	}
+	return l
+}
+
+// loadStage is one step of the load pipeline.
+type loadStage struct {
+	// name prefixes errors returned by the stage.
+	name string
+	// origin identifies the stage in the provenance and in ValidationError.Source.
+	origin Origin
+	// load runs the stage and reports the keys it read.
+	load func(cfg interface{}) (loadReport, error)
+	// appendSlices makes slices decoded by the stage append to slices set
+	// by earlier file stages.
+	appendSlices bool
+	// optional makes a failing stage be skipped instead of failing the load.
+	optional bool
+	// fingerprint, if set, writes a representation of the stage's input for Watch.
+	fingerprint func(w io.Writer) error
+}
+
+// stages returns the load pipeline, from lowest to highest priority.
+func (l *loader) stages() ([]loadStage, error) {
+	entries := l.sources
+	if len(entries) == 0 {
+		var err error
+		if entries, err = l.defaultSources(); err != nil {
+			return nil, err
+		}
+	}
+
+	stages := make([]loadStage, len(entries))
+	for i, entry := range entries {
+		stages[i] = l.stage(entry)
+	}
+	return stages, nil
 }
 
 // Load loads configuration from multiple sources with priority:
-// 1. File (if FilePath is set and file exists)
+// 1. Command-line flags (if Args is set)
 // 2. Environment variables
-// 3. Default values from struct tags
+// 3. Files (FilePath, then FilePaths, each followed by its profile overlay)
+// 4. Default values from struct tags
+//
+// Sources set with WithSources or WithSource replace this pipeline.
+//
+// The origin of every field is recorded and available from Provenance.
+// Validation errors report every failing field together with the source that
+// supplied its value.
 func (l *loader) Load(cfg interface{}) error {
-	// Step 1: Apply defaults first (lowest priority)
-	if err := l.SetDefaults(cfg); err != nil {
-		return fmt.Errorf("set defaults: %w", err)
+	if err := checkConfigPointer(cfg); err != nil {
+		return err
 	}
+	stages, err := l.stages()
+	if err != nil {
+		return err
+	}
+
+	origins := make(Provenance)
+	before := snapshotLeaves(cfg)
 
-	// Step 2: Load from file (if specified)
-	if l.config.FilePath != "" {
-		if err := l.LoadFromFile(l.config.FilePath, cfg); err != nil {
-			return fmt.Errorf("load from file: %w", err)
+	for _, stage := range stages {
+		var detached map[string]reflect.Value
+		if stage.appendSlices {
+			detached = detachSlices(cfg, origins)
+		}
+		report, err := stage.load(cfg)
+		attachSlices(cfg, detached)
+		if err != nil {
+			if stage.optional {
+				continue
+			}
+			return fmt.Errorf("%s: %w", stage.name, err)
+		}
+		after := snapshotLeaves(cfg)
+		origins.record(before, after, stage.origin, report.keys)
+		before = after
+		if l.config.OnWarning != nil {
+			for _, w := range report.warns {
+				w.Origin = stage.origin
+				l.config.OnWarning(w)
+			}
 		}
 	}
 
-	// Step 3: Load from environment variables (highest priority, overrides file)
-	if err := l.LoadFromEnv(cfg); err != nil {
-		return fmt.Errorf("load from env: %w", err)
-	}
+	l.mu.Lock()
+	l.provenance = origins
+	l.mu.Unlock()
 
-	// Step 4: Validate if enabled
+	// Validate if enabled
 	if l.config.ValidateAfterLoad {
-		if err := ValidateStruct(cfg); err != nil {
+		if err := l.validate(cfg); err != nil {
+			origins.annotate(err)
 			return fmt.Errorf("validation failed: %w", err)
 		}
 	}
@@ -73,16 +226,67 @@ func (l *loader) Load(cfg interface{}) error {
 	return nil
 }
 
+// Provenance returns the origin of each field set by the most recent Load,
+// keyed by dotted field path. Pass it to Explain to render a report.
+func (l *loader) Provenance() Provenance {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.provenance.clone()
+}
+
+// checkConfigPointer returns an error unless cfg is a non-nil pointer to a
+// struct. A struct passed by value could not be modified by the sources.
+func checkConfigPointer(cfg interface{}) error {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a non-nil pointer to a struct, got %T", cfg)
+	}
+	return nil
+}
+
+// validate validates cfg with the loader's rules in addition to the global ones.
+func (l *loader) validate(cfg interface{}) error {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a struct or pointer to struct")
+	}
+	return validateStruct(rv, l.rules)
+}
+
+// RegisterValidation registers a rule for use in validate= tags by this loader only.
+// Loader rules take precedence over rules registered with the package-level
+// RegisterValidation and over built-in rules.
+func (l *loader) RegisterValidation(name string, fn ValidationFunc) {
+	l.rules.register(name, fn)
+}
+
 // LoadFromFile loads configuration from a specific file.
 func (l *loader) LoadFromFile(path string, cfg interface{}) error {
+	return l.fileSource(path).Load(cfg)
+}
+
+// fileSource creates a file source from the loader configuration.
+func (l *loader) fileSource(path string) *FileSource {
 	source := NewFileSource(path)
-	return source.Load(cfg)
+	source.Interpolate = l.config.Interpolate
+	source.Strict = l.config.Strict
+	return source
 }
 
 // LoadFromEnv loads configuration from environment variables.
 func (l *loader) LoadFromEnv(cfg interface{}) error {
+	return l.envSource().Load(cfg)
+}
+
+// envSource creates an environment source from the loader configuration.
+func (l *loader) envSource() *EnvSource {
 	source := NewEnvSource(l.config.EnvPrefix)
-	return source.Load(cfg)
+	source.Separator = l.config.EnvSeparator
+	source.FlatNames = l.config.EnvFlatNames
+	return source
 }
 
 // SetDefaults applies default values from struct tags.
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -476,6 +478,10 @@ func TestDetectFormat(t *testing.T) {
 		{"yaml extension", "config.yaml", YAMLFormat},
 		{"yml extension", "config.yml", YAMLFormat},
 		{"json extension", "config.json", JSONFormat},
+		{"toml extension", "config.toml", TOMLFormat},
+		{"ini extension", "config.ini", INIFormat},
+		{"properties extension", "app.properties", PropertiesFormat},
+		{"upper-case extension", "CONFIG.YAML", YAMLFormat},
 		{"unknown extension", "config.txt", UnknownFormat},
 		{"no extension", "config", UnknownFormat},
 	}
@@ -589,3 +595,178 @@ func TestDefaultSource_NestedStruct(t *testing.T) {
 		t.Errorf("cfg.Outer.Inner.Value = %q, want %q", cfg.Outer.Inner.Value, "nested-default")
 	}
 }
+
+func TestEnvSource_NestedPath(t *testing.T) {
+	type PathConfig struct {
+		Host     string
+		Database struct {
+			Host    string
+			MaxIdle int
+		}
+		Cache struct {
+			Host string
+		}
+		Explicit struct {
+			Port int `config:"env=EXPLICIT_PORT"`
+		}
+	}
+
+	os.Setenv("APP_HOST", "root-host")
+	os.Setenv("APP_DATABASE_HOST", "db-host")
+	os.Setenv("APP_DATABASE_MAX_IDLE", "7")
+	os.Setenv("APP_CACHE_HOST", "cache-host")
+	os.Setenv("APP_EXPLICIT_PORT", "6379")
+	defer func() {
+		os.Unsetenv("APP_HOST")
+		os.Unsetenv("APP_DATABASE_HOST")
+		os.Unsetenv("APP_DATABASE_MAX_IDLE")
+		os.Unsetenv("APP_CACHE_HOST")
+		os.Unsetenv("APP_EXPLICIT_PORT")
+	}()
+
+	source := NewEnvSource("APP")
+	var cfg PathConfig
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Host != "root-host" {
+		t.Errorf("cfg.Host = %q, want %q", cfg.Host, "root-host")
+	}
+	if cfg.Database.Host != "db-host" {
+		t.Errorf("cfg.Database.Host = %q, want %q", cfg.Database.Host, "db-host")
+	}
+	if cfg.Database.MaxIdle != 7 {
+		t.Errorf("cfg.Database.MaxIdle = %d, want %d", cfg.Database.MaxIdle, 7)
+	}
+	if cfg.Cache.Host != "cache-host" {
+		t.Errorf("cfg.Cache.Host = %q, want %q", cfg.Cache.Host, "cache-host")
+	}
+	if cfg.Explicit.Port != 6379 {
+		t.Errorf("cfg.Explicit.Port = %d, want %d (explicit env key)", cfg.Explicit.Port, 6379)
+	}
+}
+
+// recursiveNode is a configuration type that refers to itself.
+type recursiveNode struct {
+	Name  string
+	Child *recursiveNode
+}
+
+func TestEnvSource_RecursiveType(t *testing.T) {
+	t.Setenv("NODE_NAME", "root")
+
+	var node recursiveNode
+	if err := NewEnvSource("NODE").Load(&node); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if node.Name != "root" || node.Child != nil {
+		t.Errorf("Load() = %+v, want name set and recursive pointer skipped", node)
+	}
+
+	node = recursiveNode{}
+	if err := NewLoaderWithConfig(Config{EnvPrefix: "NODE", ValidateAfterLoad: true}).Load(&node); err != nil {
+		t.Fatalf("Loader.Load() error = %v", err)
+	}
+	if node.Name != "root" {
+		t.Errorf("Loader.Load() = %+v, want name from env", node)
+	}
+}
+
+func TestEnvSource_Separator(t *testing.T) {
+	type SeparatorConfig struct {
+		Database struct {
+			Host string
+		}
+	}
+
+	os.Setenv("APP__DATABASE__HOST", "db-host")
+	defer os.Unsetenv("APP__DATABASE__HOST")
+
+	source := &EnvSource{Prefix: "APP", Separator: "__"}
+	var cfg SeparatorConfig
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Database.Host != "db-host" {
+		t.Errorf("cfg.Database.Host = %q, want %q", cfg.Database.Host, "db-host")
+	}
+}
+
+func TestEnvSource_FlatNames(t *testing.T) {
+	type FlatConfig struct {
+		Database struct {
+			Host string
+		}
+	}
+
+	os.Setenv("APP_HOST", "flat-host")
+	os.Setenv("APP_DATABASE_HOST", "path-host")
+	defer func() {
+		os.Unsetenv("APP_HOST")
+		os.Unsetenv("APP_DATABASE_HOST")
+	}()
+
+	loader := NewLoaderWithConfig(Config{
+		EnvPrefix:    "APP",
+		EnvFlatNames: true,
+	})
+
+	var cfg FlatConfig
+	if err := loader.LoadFromEnv(&cfg); err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+
+	if cfg.Database.Host != "flat-host" {
+		t.Errorf("cfg.Database.Host = %q, want %q (flat naming)", cfg.Database.Host, "flat-host")
+	}
+}
+
+func TestLoader_Load_ValidationErrorSource(t *testing.T) {
+	type SourcedConfig struct {
+		Port    int    `config:"env=SOURCED_PORT,validate=range=1,100"`
+		Retries int    `config:"default=500,validate=range=0,10"`
+		Name    string `config:"required"`
+		// Elements take the source of their slice
+		Upstreams []struct {
+			URL string `config:"validate=url"`
+		}
+	}
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(filePath, []byte("port: 50\nupstreams:\n  - url: bad\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	os.Setenv("SOURCED_PORT", "8080")
+	defer os.Unsetenv("SOURCED_PORT")
+
+	loader := NewLoaderWithConfig(Config{
+		FilePath:          filePath,
+		ValidateAfterLoad: true,
+	})
+
+	var cfg SourcedConfig
+	err := loader.Load(&cfg)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Load() error = %v, want ValidationErrors", err)
+	}
+
+	sources := make(map[string]string)
+	for _, e := range errs {
+		sources[e.Field] = e.Source
+	}
+	expected := map[string]string{
+		"Port":             "env:SOURCED_PORT",
+		"Retries":          "default",
+		"Name":             "",
+		"Upstreams[0].URL": "file:" + filePath,
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("error sources = %v, want %v", sources, expected)
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import "time"
+
+// The options below set the fields of Config, so that a loader can be built
+// with NewLoader (or Load) instead of filling in a Config struct.
+
+// WithFile sets the configuration file (Config.FilePath).
+func WithFile(path string) Option {
+	return func(l *loader) {
+		l.config.FilePath = path
+	}
+}
+
+// WithFiles adds files, directories and glob patterns loaded after the
+// configuration file (Config.FilePaths).
+func WithFiles(paths ...string) Option {
+	return func(l *loader) {
+		l.config.FilePaths = append(l.config.FilePaths, paths...)
+	}
+}
+
+// WithProfile selects the profile overlay files, such as
+// config.production.yaml (Config.Profile).
+func WithProfile(profile string) Option {
+	return func(l *loader) {
+		l.config.Profile = profile
+	}
+}
+
+// WithProfileEnv names the environment variable holding the profile
+// (Config.ProfileEnv).
+func WithProfileEnv(name string) Option {
+	return func(l *loader) {
+		l.config.ProfileEnv = name
+	}
+}
+
+// WithSliceMerge sets how slices from later files combine with earlier ones
+// (Config.SliceMerge).
+func WithSliceMerge(strategy SliceMergeStrategy) Option {
+	return func(l *loader) {
+		l.config.SliceMerge = strategy
+	}
+}
+
+// WithInterpolation expands ${VAR} references in configuration files
+// (Config.Interpolate).
+func WithInterpolation() Option {
+	return func(l *loader) {
+		l.config.Interpolate = true
+	}
+}
+
+// WithStrict makes unknown keys in configuration files an error (Config.Strict).
+func WithStrict() Option {
+	return func(l *loader) {
+		l.config.Strict = true
+	}
+}
+
+// WithEnvPrefix sets the prefix of environment variables (Config.EnvPrefix).
+func WithEnvPrefix(prefix string) Option {
+	return func(l *loader) {
+		l.config.EnvPrefix = prefix
+	}
+}
+
+// WithEnvSeparator sets the separator of environment variable keys
+// (Config.EnvSeparator).
+func WithEnvSeparator(separator string) Option {
+	return func(l *loader) {
+		l.config.EnvSeparator = separator
+	}
+}
+
+// WithEnvFlatNames keeps the legacy environment naming, where nested fields
+// are not prefixed with their parent's name (Config.EnvFlatNames).
+func WithEnvFlatNames() Option {
+	return func(l *loader) {
+		l.config.EnvFlatNames = true
+	}
+}
+
+// WithArgs parses command-line flags from args, typically os.Args[1:]
+// (Config.Args).
+func WithArgs(args []string) Option {
+	return func(l *loader) {
+		if args == nil {
+			args = []string{}
+		}
+		l.config.Args = args
+	}
+}
+
+// WithoutValidation disables validation after loading
+// (Config.ValidateAfterLoad).
+func WithoutValidation() Option {
+	return func(l *loader) {
+		l.config.ValidateAfterLoad = false
+	}
+}
+
+// WithRule registers a validation rule for use in validate= tags by this
+// loader only, like ValidationRegistrar.RegisterValidation.
+func WithRule(name string, fn ValidationFunc) Option {
+	return func(l *loader) {
+		l.RegisterValidation(name, fn)
+	}
+}
+
+// WithWatchInterval sets how often Watch polls for changes
+// (Config.WatchInterval).
+func WithWatchInterval(interval time.Duration) Option {
+	return func(l *loader) {
+		l.config.WatchInterval = interval
+	}
+}
+
+// WithWatchErrorHandler sets the function called when Watch fails to reload
+// the configuration (Config.OnWatchError).
+func WithWatchErrorHandler(fn func(err error)) Option {
+	return func(l *loader) {
+		l.config.OnWatchError = fn
+	}
+}
+
+// WithWarningHandler sets the function called for each deprecated key a
+// load reads (Config.OnWarning).
+func WithWarningHandler(fn func(w Warning)) Option {
+	return func(l *loader) {
+		l.config.OnWarning = fn
+	}
+}
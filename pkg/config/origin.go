@@ -0,0 +1,259 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origin describes where a configuration value came from.
+type Origin struct {
+	// Source is the kind of source that set the value: "default", "file" or "env".
+	Source string
+	// Key identifies the value within the source: the file path for "file"
+	// and the variable name for "env". It is empty for defaults.
+	Key string
+}
+
+// String returns the origin as "source:key", or just the source if there is no key.
+func (o Origin) String() string {
+	if o.Key == "" {
+		return o.Source
+	}
+	return o.Source + ":" + o.Key
+}
+
+// Provenance maps dotted field paths (e.g. "Database.Port") to the origin of
+// their current value. Fields that no source set are absent.
+type Provenance map[string]Origin
+
+// record marks every leaf set by a source as set by origin. keys, if non-nil,
+// lists the fields the source set, or the nested structs containing them,
+// with the per-field key used by the source (such as the environment variable
+// name), which takes precedence over origin.Key. Leaves absent from keys are
+// credited to origin if their value differs between the two snapshots, for
+// sources that do not report keys.
+func (p Provenance) record(before, after map[string]reflect.Value, origin Origin, keys map[string]string) {
+	for path, value := range after {
+		key, set := fieldKey(keys, path)
+		if !set {
+			previous, existed := before[path]
+			if existed && reflect.DeepEqual(previous.Interface(), value.Interface()) {
+				continue
+			}
+			// Leaves of a newly allocated nested struct that were left
+			// empty were not set by the source.
+			if !existed && value.IsZero() {
+				continue
+			}
+		}
+
+		fieldOrigin := origin
+		if set {
+			fieldOrigin.Key = key
+		}
+		p[path] = fieldOrigin
+	}
+}
+
+// fieldKey returns the key of the field at path, or of the closest nested
+// struct containing it, such as a struct read from an included file.
+func fieldKey(keys map[string]string, path string) (string, bool) {
+	for {
+		if key, ok := keys[path]; ok {
+			return key, true
+		}
+		i := strings.LastIndexByte(path, '.')
+		if i < 0 {
+			return "", false
+		}
+		path = path[:i]
+	}
+}
+
+// documentKeys maps the path of every field set by a configuration document
+// to key, including fields whose value the document leaves unchanged.
+// Documents that do not decode into a tree set no keys.
+func documentKeys(t reflect.Type, format Format, data []byte, key string) map[string]string {
+	keys := make(map[string]string)
+	if tree, err := documentTree(format, data); err == nil {
+		collectDocumentKeys(t, tree, format, "", key, keys)
+	}
+	return keys
+}
+
+// documentTree decodes a document into a tree. The values of YAML !include
+// nodes are kept as plain strings; the fields they set are attributed to the
+// included files by the includer.
+func documentTree(format Format, data []byte) (map[string]interface{}, error) {
+	if format != YAMLFormat || !bytes.Contains(data, []byte(includeTag)) {
+		return decodeTree(format, data)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	clearIncludeTags(&root)
+	var tree map[string]interface{}
+	return tree, root.Decode(&tree)
+}
+
+// clearIncludeTags removes the !include tags below a YAML node.
+func clearIncludeTags(node *yaml.Node) {
+	if node.Tag == includeTag {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		clearIncludeTags(child)
+	}
+}
+
+// collectDocumentKeys adds the fields of struct type t set by a decoded
+// document node to keys. Nested structs given as mappings are descended into;
+// any other value sets the field as a whole.
+func collectDocumentKeys(t reflect.Type, node map[string]interface{}, format Format, path, key string, keys map[string]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !isNestedStruct(t) {
+		return
+	}
+	for name, value := range node {
+		field, ok := documentField(t, name, format)
+		if !ok {
+			continue
+		}
+		fieldPath := joinPath(path, field.Name)
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if child, ok := value.(map[string]interface{}); ok && isNestedStruct(fieldType) {
+			collectDocumentKeys(fieldType, child, format, fieldPath, key, keys)
+			continue
+		}
+		keys[fieldPath] = key
+	}
+}
+
+// annotate fills in the Source of validation errors from the recorded origins.
+func (p Provenance) annotate(err error) {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return
+	}
+	for _, e := range errs {
+		if origin, ok := p.lookup(e.Field); ok && e.Source == "" {
+			e.Source = origin.String()
+		}
+	}
+}
+
+// lookup returns the origin of the field at path. Elements such as
+// "Upstreams[2].URL" come from their slice or map.
+func (p Provenance) lookup(path string) (Origin, bool) {
+	origin, ok := p[path]
+	if !ok {
+		if i := strings.IndexByte(path, '['); i > 0 {
+			origin, ok = p[path[:i]]
+		}
+	}
+	return origin, ok
+}
+
+// clone returns a copy of the provenance.
+func (p Provenance) clone() Provenance {
+	out := make(Provenance, len(p))
+	for path, origin := range p {
+		out[path] = origin
+	}
+	return out
+}
+
+// snapshotLeaves returns deep copies of the leaf values of a struct, keyed by
+// dotted path. Nested structs and non-nil pointers to nested structs are
+// descended into; every other exported field is a leaf.
+func snapshotLeaves(cfg interface{}) map[string]reflect.Value {
+	leaves := make(map[string]reflect.Value)
+	rv := reflect.ValueOf(cfg)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		collectLeaves(rv, "", leaves)
+	}
+	return leaves
+}
+
+// collectLeaves walks a struct value, adding its leaves to the snapshot.
+func collectLeaves(rv reflect.Value, path string, leaves map[string]reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+		switch {
+		case isNestedStruct(field.Type):
+			collectLeaves(fieldValue, fieldPath, leaves)
+		case field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem()) && !fieldValue.IsNil():
+			collectLeaves(fieldValue.Elem(), fieldPath, leaves)
+		default:
+			leaves[fieldPath] = copyValue(fieldValue)
+		}
+	}
+}
+
+// copyValue returns a deep copy of v, so that later in-place modifications
+// (such as a decoder merging into an existing map) do not alter the snapshot.
+func copyValue(v reflect.Value) reflect.Value {
+	out := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			elem := copyValue(v.Elem())
+			ptr := reflect.New(elem.Type())
+			ptr.Elem().Set(elem)
+			out.Set(ptr)
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			slice := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				slice.Index(i).Set(copyValue(v.Index(i)))
+			}
+			out.Set(slice)
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			m := reflect.MakeMapWithSize(v.Type(), v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				m.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+			}
+			out.Set(m)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(copyValue(v.Index(i)))
+		}
+	case reflect.Struct:
+		// Structs with unexported fields (time.Time, url.URL, ...) are copied
+		// by value; exported fields are copied deeply.
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+	default:
+		out.Set(v)
+	}
+	return out
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Option configures a loader created by NewLoader or NewLoaderWithConfig.
+type Option func(*loader)
+
+// SourceOption configures how a loader runs a single source.
+type SourceOption func(*sourceEntry)
+
+// ContextSource is implemented by sources that can be cancelled, such as
+// sources that make network calls. The loader calls LoadContext instead of
+// Load, with a context that expires after the source's Timeout.
+type ContextSource interface {
+	Source
+	LoadContext(ctx context.Context, cfg interface{}) error
+}
+
+// OriginSource is implemented by sources that describe themselves in
+// Provenance and ValidationError.Source. Other sources are identified by
+// their type name.
+type OriginSource interface {
+	Source
+	Origin() Origin
+}
+
+// loadReport describes what a source read during one load.
+type loadReport struct {
+	// keys maps the dotted path of each field set to the key it was read
+	// from, such as the environment variable name.
+	keys map[string]string
+	// warns holds the deprecated keys used.
+	warns []Warning
+}
+
+// reportingSource is implemented by sources that report the keys they read
+// and the deprecated keys they used. The loader calls loadReport instead of
+// Load, so that each load gets its own report even when loads of the same
+// source overlap, as with a source still running after its Timeout.
+type reportingSource interface {
+	loadReport(ctx context.Context, cfg interface{}) (loadReport, error)
+}
+
+// fingerprintSource is implemented by sources whose input Watch can poll
+// for changes. fingerprint writes a representation of the current input to w.
+type fingerprintSource interface {
+	fingerprint(w io.Writer) error
+}
+
+// sourceEntry is a source in a loader's pipeline together with its options.
+type sourceEntry struct {
+	source   Source
+	optional bool
+	timeout  time.Duration
+}
+
+// WithSources replaces the default defaults → files → env pipeline with the
+// given sources, run in order so that later sources override earlier ones.
+//
+// Example:
+//
+//	loader := config.NewLoader(config.WithSources(
+//		config.NewDefaultSource(),
+//		config.NewFileSource("config.yaml"),
+//		config.NewEnvSource("APP"),
+//	))
+func WithSources(sources ...Source) Option {
+	return func(l *loader) {
+		for _, source := range sources {
+			l.sources = append(l.sources, sourceEntry{source: source})
+		}
+	}
+}
+
+// WithSource adds a single source to the pipeline, after any sources added
+// before it, with per-source options.
+//
+// Example:
+//
+//	loader := config.NewLoader(
+//		config.WithSources(config.NewDefaultSource(), config.NewFileSource("config.yaml")),
+//		config.WithSource(vaultSource, config.Optional(), config.Timeout(2*time.Second)),
+//	)
+func WithSource(source Source, opts ...SourceOption) Option {
+	return func(l *loader) {
+		entry := sourceEntry{source: source}
+		for _, opt := range opts {
+			opt(&entry)
+		}
+		l.sources = append(l.sources, entry)
+	}
+}
+
+// Optional makes the loader skip a source that fails instead of failing the
+// whole load. A failed optional source leaves the configuration unchanged.
+func Optional() SourceOption {
+	return func(e *sourceEntry) {
+		e.optional = true
+	}
+}
+
+// Timeout limits how long a source may take to load. A source that does not
+// finish in time fails (or is skipped, if Optional) and leaves the
+// configuration unchanged. ContextSource implementations are cancelled.
+func Timeout(d time.Duration) SourceOption {
+	return func(e *sourceEntry) {
+		e.timeout = d
+	}
+}
+
+// defaultSources returns the default pipeline: tag defaults, the
+// configuration files, the environment and command-line flags.
+func (l *loader) defaultSources() ([]sourceEntry, error) {
+	entries := []sourceEntry{{source: NewDefaultSource()}}
+
+	files, err := l.configFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range files {
+		entries = append(entries, sourceEntry{source: l.fileSource(path)})
+	}
+
+	entries = append(entries, sourceEntry{source: l.envSource()})
+
+	if l.config.Args != nil {
+		entries = append(entries, sourceEntry{source: NewFlagSource(l.config.Args)})
+	}
+	return entries, nil
+}
+
+// stage turns a pipeline entry into a load stage.
+func (l *loader) stage(entry sourceEntry) loadStage {
+	// Errors of sources name their file, URL or variable: the stage names
+	// only the kind of source, so that they are not named twice.
+	origin := sourceOrigin(entry.source)
+	stage := loadStage{
+		name:     "load from " + origin.Source,
+		origin:   origin,
+		load:     entry.load,
+		optional: entry.optional,
+	}
+
+	if fp, ok := entry.source.(fingerprintSource); ok {
+		stage.fingerprint = fp.fingerprint
+	}
+	if _, ok := entry.source.(*FileSource); ok {
+		stage.appendSlices = l.config.SliceMerge == SliceAppend
+	}
+	return stage
+}
+
+// sourceOrigin returns the origin of a source, falling back to its type name.
+func sourceOrigin(source Source) Origin {
+	if named, ok := source.(OriginSource); ok {
+		return named.Origin()
+	}
+	name := reflect.TypeOf(source).String()
+	return Origin{Source: name[strings.LastIndex(name, ".")+1:]}
+}
+
+// load runs the source. Optional sources and sources with a timeout load
+// into a copy of cfg, which replaces cfg only on success, so that a failed
+// or abandoned load has no effect.
+func (e sourceEntry) load(cfg interface{}) (loadReport, error) {
+	if !e.optional && e.timeout <= 0 {
+		return loadSource(context.Background(), e.source, cfg)
+	}
+
+	if err := checkConfigPointer(cfg); err != nil {
+		return loadReport{}, err
+	}
+	rv := reflect.ValueOf(cfg)
+	working := reflect.New(rv.Elem().Type())
+	working.Elem().Set(copyValue(rv.Elem()))
+
+	ctx := context.Background()
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	// The source runs in its own goroutine so that sources ignoring the
+	// context cannot block the loader past the timeout.
+	type result struct {
+		report loadReport
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		report, err := loadSource(ctx, e.source, working.Interface())
+		done <- result{report, err}
+	}()
+
+	var report loadReport
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return loadReport{}, r.err
+		}
+		report = r.report
+	case <-ctx.Done():
+		if key := sourceOrigin(e.source).Key; key != "" {
+			return loadReport{}, fmt.Errorf("%s: timed out after %s", key, e.timeout)
+		}
+		return loadReport{}, fmt.Errorf("timed out after %s", e.timeout)
+	}
+
+	rv.Elem().Set(working.Elem())
+	return report, nil
+}
+
+// loadSource loads a source, passing ctx to sources that accept one, and
+// returns the report of sources that give one.
+func loadSource(ctx context.Context, source Source, cfg interface{}) (loadReport, error) {
+	if rs, ok := source.(reportingSource); ok {
+		return rs.loadReport(ctx, cfg)
+	}
+	if cs, ok := source.(ContextSource); ok {
+		return loadReport{}, cs.LoadContext(ctx, cfg)
+	}
+	return loadReport{}, source.Load(cfg)
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pipelineConfig struct {
+	Host  string `config:"default=localhost"`
+	Port  int    `config:"default=8080"`
+	Token string
+}
+
+// funcSource adapts a function to the Source interface.
+type funcSource func(cfg interface{}) error
+
+func (f funcSource) Load(cfg interface{}) error {
+	return f(cfg)
+}
+
+// vaultSource is a named source that sets the token.
+type vaultSource struct {
+	token string
+	delay time.Duration
+}
+
+func (s *vaultSource) Load(cfg interface{}) error {
+	time.Sleep(s.delay)
+	cfg.(*pipelineConfig).Token = s.token
+	return nil
+}
+
+func (s *vaultSource) Origin() Origin {
+	return Origin{Source: "vault", Key: "secret/app"}
+}
+
+// ctxSource reports whether it received a context with a deadline.
+type ctxSource struct {
+	hadDeadline chan bool
+}
+
+func (s *ctxSource) Load(cfg interface{}) error {
+	return errors.New("Load should not be called on a ContextSource")
+}
+
+func (s *ctxSource) LoadContext(ctx context.Context, cfg interface{}) error {
+	_, ok := ctx.Deadline()
+	s.hadDeadline <- ok
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestLoader_WithSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(filePath, []byte("host: file.local\nport: 9000\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	os.Setenv("PIPE_HOST", "env.local")
+	defer os.Unsetenv("PIPE_HOST")
+
+	// The environment comes before the file, so the file wins.
+	loader := NewLoader(
+		WithSources(NewDefaultSource(), NewEnvSource("PIPE"), NewFileSource(filePath)),
+		WithSource(&vaultSource{token: "t0k"}),
+		WithSource(funcSource(func(cfg interface{}) error {
+			cfg.(*pipelineConfig).Port++
+			return nil
+		})),
+	)
+
+	var cfg pipelineConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Host != "file.local" || cfg.Port != 9001 || cfg.Token != "t0k" {
+		t.Errorf("Load() = %+v, want file host, incremented port and vault token", cfg)
+	}
+
+	prov := loader.(ProvenanceReporter).Provenance()
+	expected := map[string]string{
+		"Host":  "file:" + filePath,
+		"Port":  "funcSource",
+		"Token": "vault:secret/app",
+	}
+	for path, want := range expected {
+		if got := prov[path].String(); got != want {
+			t.Errorf("Provenance()[%s] = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestLoader_WithSource_Optional(t *testing.T) {
+	failing := funcSource(func(cfg interface{}) error {
+		cfg.(*pipelineConfig).Host = "partial"
+		return errors.New("unavailable")
+	})
+
+	var cfg pipelineConfig
+	loader := NewLoader(WithSources(NewDefaultSource()), WithSource(failing, Optional()))
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v, want optional source to be skipped", err)
+	}
+	if cfg.Host != "localhost" {
+		t.Errorf("cfg.Host = %q, want %q (failed source must not leave partial values)", cfg.Host, "localhost")
+	}
+
+	// Sources are required by default
+	loader = NewLoader(WithSources(NewDefaultSource()), WithSource(failing))
+	err := loader.Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "load from funcSource: unavailable") {
+		t.Errorf("Load() error = %v, want error from required source", err)
+	}
+
+	// A missing optional file is skipped
+	loader = NewLoader(WithSources(NewDefaultSource()), WithSource(NewFileSource("missing.yaml"), Optional()))
+	if err := loader.Load(&cfg); err != nil {
+		t.Errorf("Load() error = %v, want missing optional file to be skipped", err)
+	}
+
+	// The error of a file names its path once
+	filePath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filePath, []byte("host: ok\nport: http\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	err = NewLoader(WithSource(NewFileSource(filePath))).Load(&cfg)
+	if err == nil || !strings.HasPrefix(err.Error(), "load from file: "+filePath+":2") || strings.Count(err.Error(), filePath) != 1 {
+		t.Errorf("Load() error = %v, want the file path once", err)
+	}
+}
+
+func TestLoader_WithSource_Timeout(t *testing.T) {
+	slow := &vaultSource{token: "late", delay: 200 * time.Millisecond}
+
+	var cfg pipelineConfig
+	loader := NewLoader(WithSource(slow, Timeout(20*time.Millisecond)))
+	err := loader.Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 20ms") {
+		t.Errorf("Load() error = %v, want timeout", err)
+	}
+
+	loader = NewLoader(WithSource(slow, Timeout(20*time.Millisecond), Optional()))
+	if err := loader.Load(&cfg); err != nil {
+		t.Errorf("Load() error = %v, want optional source to be skipped", err)
+	}
+	if cfg.Token != "" {
+		t.Errorf("cfg.Token = %q, want abandoned source not to modify the config", cfg.Token)
+	}
+
+	fast := &vaultSource{token: "t0k"}
+	loader = NewLoader(WithSource(fast, Timeout(time.Second)))
+	if err := loader.Load(&cfg); err != nil || cfg.Token != "t0k" {
+		t.Errorf("Load() = %+v, %v, want token from fast source", cfg, err)
+	}
+
+	source := &ctxSource{hadDeadline: make(chan bool, 1)}
+	loader = NewLoader(WithSource(source, Timeout(10*time.Millisecond)))
+	if err := loader.Load(&cfg); err == nil {
+		t.Error("Load() should fail when the context source times out")
+	}
+	if !<-source.hadDeadline {
+		t.Error("LoadContext() should receive a context with the timeout as deadline")
+	}
+}
+
+func TestLoader_Watch_WithSources(t *testing.T) {
+	ctx := context.Background()
+	var cfg pipelineConfig
+	noop := func(interface{}) {}
+
+	envOnly := NewLoader(WithSources(NewDefaultSource(), NewEnvSource("PIPE"))).(Watcher)
+	if err := envOnly.Watch(ctx, &cfg, noop); !errors.Is(err, errNothingToWatch) {
+		t.Errorf("Watch() error = %v, want errNothingToWatch", err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filePath, []byte("port: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	l := NewLoader(WithSources(NewFileSource(filePath))).(*loader)
+	if _, err := l.sourcesFingerprint(); err != nil {
+		t.Errorf("sourcesFingerprint() error = %v, want file source to be watched", err)
+	}
+}
+
+func TestLoader_Load_Concurrent(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filePath, []byte("port: 9000\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	t.Setenv("PIPERACE_HOST", "db.local")
+
+	// The file source also runs in a stage that times out, so that its
+	// abandoned loads overlap with the loads of other stages and goroutines.
+	file := NewFileSource(filePath)
+	slow := funcSource(func(cfg interface{}) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	loader := NewLoader(
+		WithSources(NewDefaultSource(), file),
+		WithSource(file, Timeout(time.Nanosecond), Optional()),
+		WithSource(slow, Timeout(time.Millisecond), Optional()),
+		WithSource(NewEnvSource("PIPERACE"), Timeout(time.Second)),
+	)
+
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			for j := 0; j < 5; j++ {
+				var cfg pipelineConfig
+				if err := loader.Load(&cfg); err != nil {
+					errs <- err
+					return
+				}
+				if cfg.Host != "db.local" || cfg.Port != 9000 {
+					errs <- fmt.Errorf("Load() = %+v, want host from env and port from file", cfg)
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	prov := loader.(ProvenanceReporter).Provenance()
+	if got := prov["Host"]; got != (Origin{Source: "env", Key: "PIPERACE_HOST"}) {
+		t.Errorf("Provenance()[Host] = %v, want env:PIPERACE_HOST", got)
+	}
+	if got := prov["Port"]; got != (Origin{Source: "file", Key: filePath}) {
+		t.Errorf("Provenance()[Port] = %v, want file", got)
+	}
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RemoteSource loads configuration from a document served over HTTP, such
+// as a shared config server.
+//
+// The format is taken from Format if set, otherwise from the response's
+// Content-Type (JSON, YAML or TOML media types), then from the extension of
+// the URL path, falling back to YAML, which also accepts JSON.
+//
+// Responses carrying an ETag are revalidated with If-None-Match, so polling
+// an unchanged document costs a 304 without a body. A new document replaces
+// the last good one only once it decodes; a document that fails to decode
+// fails the Load. A Load that cannot reach the server decodes the last good
+// document instead of failing, and reports the error to OnFallback. When
+// CachePath is set, the last good document is also written there, so that a
+// new process can start from it.
+//
+// RemoteSource implements ContextSource, so Timeout cancels the request, and
+// Watch polls it like a file, reloading when the document changes.
+//
+// Example:
+//
+//	remote := config.NewRemoteSource("https://config.internal/billing.yaml")
+//	remote.CachePath = "/var/cache/billing/config.yaml"
+//	remote.Header.Set("Authorization", "Bearer "+token)
+//
+//	loader := config.NewLoader(
+//		config.WithSources(config.NewDefaultSource()),
+//		config.WithSource(remote, config.Timeout(5*time.Second)),
+//		config.WithSources(config.NewEnvSource("APP")),
+//	)
+type RemoteSource struct {
+	URL string
+	// Client sends the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// Header is added to every request, e.g. for authentication.
+	Header http.Header
+	// Format overrides the format detected from the response.
+	Format Format
+	// CachePath is the file holding the last document that decoded
+	// successfully (optional). It is written with mode 0600. Unless Format
+	// is set, give it the extension of the served format.
+	CachePath string
+	// Strict makes keys that match no field an error.
+	Strict bool
+	// OnFallback, if set, is called with the fetch error when a Load uses
+	// the last good document because the server could not be reached or
+	// returned an error status.
+	OnFallback func(err error)
+
+	mu sync.Mutex
+	// good is the last document that decoded successfully, or the cached
+	// copy; its data is nil until there is one.
+	good remoteDocument
+	// pending is a new document fetched by fingerprint, which the next Load
+	// decodes instead of fetching the document again, or nil.
+	pending *remoteDocument
+}
+
+// remoteDocument is a document fetched by RemoteSource.
+type remoteDocument struct {
+	data   []byte
+	etag   string
+	format Format
+}
+
+// NewRemoteSource creates a new source fetching rawURL.
+func NewRemoteSource(rawURL string) *RemoteSource {
+	return &RemoteSource{URL: rawURL, Header: make(http.Header)}
+}
+
+// Origin identifies the URL in Provenance.
+func (s *RemoteSource) Origin() Origin {
+	return Origin{Source: "remote", Key: s.URL}
+}
+
+// Load fetches and decodes the document.
+func (s *RemoteSource) Load(cfg interface{}) error {
+	return s.LoadContext(context.Background(), cfg)
+}
+
+// LoadContext fetches and decodes the document, falling back to the last
+// good document if the server cannot be reached or returns an error status.
+func (s *RemoteSource) LoadContext(ctx context.Context, cfg interface{}) error {
+	_, err := s.loadReport(ctx, cfg)
+	return err
+}
+
+// loadReport fetches and decodes the document and reports the deprecated
+// keys used.
+func (s *RemoteSource) loadReport(ctx context.Context, cfg interface{}) (loadReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report loadReport
+	warn := func(w Warning) { report.warns = append(report.warns, w) }
+	doc, fetchErr := s.pending, error(nil)
+	s.pending = nil
+	if doc == nil {
+		doc, fetchErr = s.fetch(ctx)
+	}
+	if fetchErr != nil {
+		if s.good.data == nil && !s.readCache() {
+			return report, fetchErr
+		}
+		if s.OnFallback != nil {
+			s.OnFallback(fetchErr)
+		}
+		return report, decodeFormat(s.URL, s.good.format, s.good.data, cfg, s.Strict, warn, nil)
+	}
+	if doc == nil {
+		return report, decodeFormat(s.URL, s.good.format, s.good.data, cfg, s.Strict, warn, nil)
+	}
+
+	if err := decodeFormat(s.URL, doc.format, doc.data, cfg, s.Strict, warn, nil); err != nil {
+		return report, err
+	}
+	s.good = *doc
+	s.writeCache()
+	return report, nil
+}
+
+// fingerprint revalidates the document for Watch and writes its URL and
+// content. A new document is kept as pending without being decoded, so that
+// the reload Watch triggers decodes, and reports, the document fingerprinted
+// rather than fetching it again.
+func (s *RemoteSource) fingerprint(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := s.fetch(context.Background())
+	if err != nil {
+		return err
+	}
+	if doc != nil {
+		s.pending = doc
+	}
+	data := s.latest().data
+	fmt.Fprintf(w, "%s\x00%d\x00", s.URL, len(data))
+	_, err = w.Write(data)
+	return err
+}
+
+// latest returns the pending document if there is one, otherwise the last
+// good one. s.mu must be held.
+func (s *RemoteSource) latest() *remoteDocument {
+	if s.pending != nil {
+		return s.pending
+	}
+	return &s.good
+}
+
+// fetch requests the document, revalidating the latest document if it has
+// an ETag. It returns nil if the latest document is still current. s.mu must
+// be held.
+func (s *RemoteSource) fetch(ctx context.Context) (*remoteDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("remote source: %w", err)
+	}
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+	latest := s.latest()
+	if latest.etag != "" && latest.data != nil {
+		req.Header.Set("If-None-Match", latest.etag)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote source: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && latest.data != nil:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("remote source: GET %s: unexpected status %s", s.URL, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("remote source: read %s: %w", s.URL, err)
+	}
+	return &remoteDocument{
+		data:   data,
+		etag:   resp.Header.Get("ETag"),
+		format: s.detectFormat(resp.Header.Get("Content-Type")),
+	}, nil
+}
+
+// detectFormat returns the format of the document for a response Content-Type.
+func (s *RemoteSource) detectFormat(contentType string) Format {
+	if s.Format != "" {
+		return s.Format
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			return JSONFormat
+		case strings.HasSuffix(mediaType, "/yaml") || strings.HasSuffix(mediaType, "/x-yaml") ||
+			strings.HasSuffix(mediaType, "+yaml"):
+			return YAMLFormat
+		case strings.HasSuffix(mediaType, "/toml"):
+			return TOMLFormat
+		}
+	}
+	if u, err := url.Parse(s.URL); err == nil {
+		if format := DetectFormat(path.Base(u.Path)); format != UnknownFormat {
+			return format
+		}
+	}
+	return YAMLFormat
+}
+
+// readCache loads the cached document as the last good one and reports
+// whether there was one. s.mu must be held.
+func (s *RemoteSource) readCache() bool {
+	if s.CachePath == "" {
+		return false
+	}
+	data, err := os.ReadFile(s.CachePath)
+	if err != nil {
+		return false
+	}
+	s.good = remoteDocument{data: data, format: s.detectFormat("")}
+	if s.Format == "" {
+		if format := DetectFormat(s.CachePath); format != UnknownFormat {
+			s.good.format = format
+		}
+	}
+	return true
+}
+
+// writeCache replaces the cached document with the last good one. The file
+// is replaced atomically, so a crash never leaves a truncated cache. Errors
+// are ignored: the cache is only a fallback and the load itself succeeded.
+// s.mu must be held.
+func (s *RemoteSource) writeCache() {
+	if s.CachePath == "" {
+		return
+	}
+	if cached, err := os.ReadFile(s.CachePath); err == nil && string(cached) == string(s.good.data) {
+		return
+	}
+	dir := filepath.Dir(s.CachePath)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.CachePath)+".*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(s.good.data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.CachePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// configServer serves a document with an ETag derived from its version.
+type configServer struct {
+	mu          sync.Mutex
+	body        string
+	contentType string
+	version     int
+	notModified int
+	fetched     int
+}
+
+func (s *configServer) set(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+	s.version++
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	etag := `"v` + strconv.Itoa(s.version) + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.fetched++
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", s.contentType)
+	w.Write([]byte(s.body))
+}
+
+type remoteConfig struct {
+	Name string
+	Port int
+}
+
+func TestRemoteSource_Load(t *testing.T) {
+	server := &configServer{body: `{"name": "billing", "port": 8080}`, contentType: "application/json; charset=utf-8"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source := NewRemoteSource(ts.URL + "/config")
+	source.Header.Set("Authorization", "Bearer secret")
+
+	var cfg remoteConfig
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Name != "billing" || cfg.Port != 8080 {
+		t.Errorf("Load() = %+v", cfg)
+	}
+
+	// An unchanged document is revalidated with the ETag
+	cfg = remoteConfig{}
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+	if cfg.Port != 8080 || server.notModified != 1 {
+		t.Errorf("second Load() = %+v with %d not-modified responses, want 1", cfg, server.notModified)
+	}
+
+	// The format follows the Content-Type
+	server.contentType = "application/yaml"
+	server.set("name: billing\nport: 9090\n")
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("Load() after change error = %v", err)
+	}
+	if cfg.Port != 9090 {
+		t.Errorf("Load() after change = %+v, want port 9090", cfg)
+	}
+
+	unauthorized := NewRemoteSource(ts.URL + "/config")
+	err := unauthorized.Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 401 Unauthorized") {
+		t.Errorf("Load() error = %v, want unexpected status", err)
+	}
+}
+
+func TestRemoteSource_Cache(t *testing.T) {
+	server := &configServer{body: "name: billing\nport: 8080\n", contentType: "text/plain"}
+	ts := httptest.NewServer(server)
+	cachePath := filepath.Join(t.TempDir(), "cache", "billing.yaml")
+
+	source := NewRemoteSource(ts.URL + "/billing.yaml")
+	source.Header.Set("Authorization", "Bearer secret")
+	source.CachePath = cachePath
+
+	var cfg remoteConfig
+	if err := source.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("cache mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A document that fails to decode is not cached
+	server.set("name: [broken\n")
+	if err := source.Load(&cfg); err == nil {
+		t.Error("Load() should fail for an invalid document")
+	}
+	if data, _ := os.ReadFile(cachePath); string(data) != "name: billing\nport: 8080\n" {
+		t.Errorf("cache = %q, want the last good document", data)
+	}
+
+	// A new process starts from the cache while the server is down
+	ts.Close()
+	restarted := &RemoteSource{URL: ts.URL + "/billing.yaml", CachePath: cachePath}
+	cfg = remoteConfig{}
+	if err := restarted.Load(&cfg); err != nil {
+		t.Fatalf("Load() from cache error = %v", err)
+	}
+	if cfg.Name != "billing" || cfg.Port != 8080 {
+		t.Errorf("Load() from cache = %+v", cfg)
+	}
+
+	noCache := NewRemoteSource(ts.URL + "/billing.yaml")
+	if err := noCache.Load(&cfg); err == nil || !strings.HasPrefix(err.Error(), "remote source:") {
+		t.Errorf("Load() error = %v, want remote source error", err)
+	}
+}
+
+func TestRemoteSource_LastGood(t *testing.T) {
+	server := &configServer{body: "name: billing\nport: 9000\n", contentType: "application/yaml"}
+	ts := httptest.NewServer(server)
+
+	source := NewRemoteSource(ts.URL + "/billing.yaml")
+	source.Header.Set("Authorization", "Bearer secret")
+	var fallbacks []error
+	source.OnFallback = func(err error) { fallbacks = append(fallbacks, err) }
+	l := NewLoader(WithSources(source))
+
+	var cfg remoteConfig
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// A document that fails to decode fails the load and is not kept
+	server.set("name: [broken\n")
+	if err := l.Load(&remoteConfig{}); err == nil {
+		t.Error("Load() should fail for an invalid document")
+	}
+
+	// Nor is its ETag: once the server is down, the last good document is
+	// served
+	ts.Close()
+	cfg = remoteConfig{}
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() with the server down error = %v", err)
+	}
+	if cfg.Name != "billing" || cfg.Port != 9000 {
+		t.Errorf("Load() with the server down = %+v, want port 9000", cfg)
+	}
+	if len(fallbacks) != 1 || !strings.HasPrefix(fallbacks[0].Error(), "remote source:") {
+		t.Errorf("OnFallback called with %v, want one fetch error", fallbacks)
+	}
+}
+
+func TestRemoteSource_Watch(t *testing.T) {
+	server := &configServer{body: "name: billing\nport: 8080\n", contentType: "application/x-yaml"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source := NewRemoteSource(ts.URL)
+	source.Header.Set("Authorization", "Bearer secret")
+	changes := make(chan *remoteConfig, 4)
+	l := NewLoader(
+		WithSource(source, Timeout(time.Second)),
+		WithWatchInterval(10*time.Millisecond),
+	)
+
+	var cfg remoteConfig
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := l.(ProvenanceReporter).Provenance()["Port"].String(); got != "remote:"+ts.URL {
+		t.Errorf("Provenance()[Port] = %q", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.(Watcher).Watch(ctx, &cfg, func(c interface{}) { changes <- c.(*remoteConfig) })
+	time.Sleep(50 * time.Millisecond)
+
+	server.set("name: billing\nport: 9090\n")
+	select {
+	case got := <-changes:
+		if got.Port != 9090 {
+			t.Errorf("reloaded config = %+v, want port 9090", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+
+	// The reload decodes the document fetched by the poll that saw the change
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.fetched != 2 {
+		t.Errorf("document fetched %d times, want 2", server.fetched)
+	}
+}
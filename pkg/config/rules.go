@@ -0,0 +1,345 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationFunc checks a value against a validation rule. param is the part
+// of the rule after "=" (e.g. "USD|EUR" for "currency=USD|EUR"), or empty for
+// rules without a parameter. A returned error becomes the Message of the
+// field's ValidationError.
+type ValidationFunc func(value interface{}, param string) error
+
+// ruleRegistry is a concurrency-safe set of named validation rules.
+type ruleRegistry struct {
+	mu    sync.RWMutex
+	rules map[string]ValidationFunc
+}
+
+// newRuleRegistry creates an empty rule registry.
+func newRuleRegistry() *ruleRegistry {
+	return &ruleRegistry{rules: make(map[string]ValidationFunc)}
+}
+
+// register adds or replaces a rule. It panics on an empty name, a name
+// containing rule-expression separators, or a nil function, since these are
+// programming errors.
+func (r *ruleRegistry) register(name string, fn ValidationFunc) {
+	if name == "" || strings.ContainsAny(name, "=;,' ") {
+		panic(fmt.Sprintf("config: invalid validation rule name %q", name))
+	}
+	if fn == nil {
+		panic(fmt.Sprintf("config: nil validation function for rule %q", name))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules[name] = fn
+}
+
+// lookup returns the rule registered under name. It is safe to call on a nil registry.
+func (r *ruleRegistry) lookup(name string) (ValidationFunc, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.rules[name]
+	return fn, ok
+}
+
+// globalRules holds the rules registered with RegisterValidation.
+var globalRules = newRuleRegistry()
+
+// RegisterValidation registers a rule for use in validate= tags by every
+// loader and by ValidateStruct. Registering an existing name, including a
+// built-in one, replaces it. Use ValidationRegistrar.RegisterValidation
+// to scope a rule to a single loader.
+//
+// Example:
+//
+//	config.RegisterValidation("currency", func(value interface{}, param string) error {
+//		if !isISO4217(fmt.Sprint(value)) {
+//			return fmt.Errorf("unknown currency code")
+//		}
+//		return nil
+//	})
+//
+//	type Billing struct {
+//		Currency string `config:"default=USD,validate=currency"`
+//	}
+func RegisterValidation(name string, fn ValidationFunc) {
+	globalRules.register(name, fn)
+}
+
+// lookupRule finds a rule by name, preferring loader-specific rules, then
+// globally registered rules, then built-in rules.
+func lookupRule(name string, local *ruleRegistry) (ValidationFunc, bool) {
+	if fn, ok := local.lookup(name); ok {
+		return fn, true
+	}
+	if fn, ok := globalRules.lookup(name); ok {
+		return fn, true
+	}
+	fn, ok := builtinRules[name]
+	return fn, ok
+}
+
+// builtinRules maps rule names usable in validate= tags to their implementation.
+var builtinRules = map[string]ValidationFunc{
+	"email":       func(value interface{}, _ string) error { return validateEmail(value) },
+	"url":         func(value interface{}, _ string) error { return validateURL(value) },
+	"range":       func(value interface{}, param string) error { return validateRange(param, value) },
+	"oneof":       validateOneOf,
+	"min_len":     validateMinLen,
+	"max_len":     validateMaxLen,
+	"regex":       validateRegex,
+	"hostport":    validateHostPort,
+	"ip":          validateIP,
+	"cidr":        validateCIDR,
+	"duration":    validateDuration,
+	"file_exists": validateFileExists,
+	"dir_exists":  validateDirExists,
+	"port":        validatePort,
+}
+
+// validateOneOf checks that the value equals one of the "|"-separated options.
+// Example: "oneof=debug|info|warn"
+func validateOneOf(value interface{}, param string) error {
+	if param == "" {
+		return fmt.Errorf("oneof validator requires a list of values")
+	}
+
+	actual := fmt.Sprint(value)
+	options := strings.Split(param, "|")
+	for _, option := range options {
+		if actual == strings.TrimSpace(option) {
+			return nil
+		}
+	}
+	return fmt.Errorf("value must be one of [%s]", strings.Join(options, ", "))
+}
+
+// validateMinLen checks the minimum length of a string (in characters), slice or map.
+func validateMinLen(value interface{}, param string) error {
+	limit, length, err := lengthOf(value, param, "min_len")
+	if err != nil {
+		return err
+	}
+	if length < limit {
+		return fmt.Errorf("length %d is less than minimum %d", length, limit)
+	}
+	return nil
+}
+
+// validateMaxLen checks the maximum length of a string (in characters), slice or map.
+func validateMaxLen(value interface{}, param string) error {
+	limit, length, err := lengthOf(value, param, "max_len")
+	if err != nil {
+		return err
+	}
+	if length > limit {
+		return fmt.Errorf("length %d is greater than maximum %d", length, limit)
+	}
+	return nil
+}
+
+// lengthOf parses a length limit and measures the value for min_len and max_len.
+func lengthOf(value interface{}, param, ruleName string) (limit, length int, err error) {
+	limit, err = strconv.Atoi(param)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s validator requires an integer length: %w", ruleName, err)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return limit, utf8.RuneCountInString(rv.String()), nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return limit, rv.Len(), nil
+	default:
+		return 0, 0, fmt.Errorf("%s validator requires string, slice or map value", ruleName)
+	}
+}
+
+// regexCache holds compiled patterns, keyed by pattern string.
+var regexCache sync.Map
+
+// validateRegex checks that a string matches the pattern.
+// The pattern is not anchored implicitly; use ^ and $ to match the whole value.
+func validateRegex(value interface{}, param string) error {
+	str, ok := stringValue(value)
+	if !ok {
+		return fmt.Errorf("regex validator requires string value")
+	}
+
+	pattern, err := compileRegex(param)
+	if err != nil {
+		return err
+	}
+	if !pattern.MatchString(str) {
+		return fmt.Errorf("value does not match pattern %q", param)
+	}
+	return nil
+}
+
+// compileRegex compiles a pattern once and caches it.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := regexCache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+	}
+	regexCache.Store(pattern, compiled)
+	return compiled, nil
+}
+
+// validateHostPort checks a "host:port" address. The host may be empty (":8080").
+func validateHostPort(value interface{}, _ string) error {
+	str, ok := stringValue(value)
+	if !ok {
+		return fmt.Errorf("hostport validator requires string value")
+	}
+
+	_, port, err := net.SplitHostPort(str)
+	if err != nil {
+		return fmt.Errorf("invalid host:port: %w", err)
+	}
+	return checkPort(port)
+}
+
+// validateIP checks an IPv4 or IPv6 address.
+func validateIP(value interface{}, _ string) error {
+	str, ok := stringValue(value)
+	if !ok {
+		return fmt.Errorf("ip validator requires string value")
+	}
+
+	if net.ParseIP(str) == nil {
+		return fmt.Errorf("invalid IP address")
+	}
+	return nil
+}
+
+// validateCIDR checks a CIDR notation network such as "10.0.0.0/8".
+func validateCIDR(value interface{}, _ string) error {
+	str, ok := stringValue(value)
+	if !ok {
+		return fmt.Errorf("cidr validator requires string value")
+	}
+
+	if _, _, err := net.ParseCIDR(str); err != nil {
+		return fmt.Errorf("invalid CIDR: %w", err)
+	}
+	return nil
+}
+
+// validateDuration checks a string in time.ParseDuration syntax.
+// time.Duration and Duration values are always valid.
+func validateDuration(value interface{}, _ string) error {
+	switch value.(type) {
+	case time.Duration, Duration:
+		return nil
+	}
+
+	str, ok := stringValue(value)
+	if !ok {
+		return fmt.Errorf("duration validator requires string or time.Duration value")
+	}
+
+	if _, err := time.ParseDuration(str); err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	return nil
+}
+
+// validateFileExists checks that the value is the path of an existing regular file.
+func validateFileExists(value interface{}, _ string) error {
+	info, err := statPath(value, "file_exists")
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("path is a directory, not a file")
+	}
+	return nil
+}
+
+// validateDirExists checks that the value is the path of an existing directory.
+func validateDirExists(value interface{}, _ string) error {
+	info, err := statPath(value, "dir_exists")
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("path is not a directory")
+	}
+	return nil
+}
+
+// statPath stats the path held by value for file_exists and dir_exists.
+func statPath(value interface{}, ruleName string) (os.FileInfo, error) {
+	path, ok := stringValue(value)
+	if !ok {
+		return nil, fmt.Errorf("%s validator requires string value", ruleName)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist")
+		}
+		return nil, fmt.Errorf("cannot access path: %w", err)
+	}
+	return info, nil
+}
+
+// validatePort checks a TCP/UDP port number (1-65535), given as an integer or a numeric string.
+func validatePort(value interface{}, _ string) error {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return checkPort(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return checkPort(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.String:
+		return checkPort(rv.String())
+	default:
+		return fmt.Errorf("port validator requires integer or string value")
+	}
+}
+
+// checkPort checks that a string is a port number between 1 and 65535.
+func checkPort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("port %d is out of range 1-65535", n)
+	}
+	return nil
+}
+
+// stringValue returns the value as a string if its kind is string,
+// including named string types.
+func stringValue(value interface{}) (string, bool) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.String {
+		return "", false
+	}
+	return rv.String(), true
+}
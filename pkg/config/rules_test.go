@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuiltinRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "cert.pem")
+	if err := os.WriteFile(filePath, []byte("cert"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	type level string
+
+	tests := []struct {
+		name    string
+		rule    string
+		value   interface{}
+		wantErr bool
+	}{
+		{"oneof match", "oneof=debug|info|warn", "info", false},
+		{"oneof named string type", "oneof=debug|info", level("debug"), false},
+		{"oneof integer", "oneof=1|2|3", 2, false},
+		{"oneof mismatch", "oneof=debug|info|warn", "trace", true},
+		{"oneof without values", "oneof", "x", true},
+		{"min_len ok", "min_len=3", "abc", false},
+		{"min_len counts characters", "min_len=3", "äöü", false},
+		{"min_len too short", "min_len=3", "ab", true},
+		{"min_len slice", "min_len=2", []string{"a"}, true},
+		{"max_len ok", "max_len=2", map[string]int{"a": 1}, false},
+		{"max_len too long", "max_len=2", "abc", true},
+		{"max_len invalid param", "max_len=x", "abc", true},
+		{"max_len unsupported type", "max_len=1", 10, true},
+		{"regex match", "regex=^[a-z]+$", "abc", false},
+		{"regex mismatch", "regex=^[a-z]+$", "ABC", true},
+		{"regex invalid pattern", "regex=[", "abc", true},
+		{"regex non-string", "regex=.*", 1, true},
+		{"hostport", "hostport", "db.local:5432", false},
+		{"hostport empty host", "hostport", ":8080", false},
+		{"hostport missing port", "hostport", "db.local", true},
+		{"hostport invalid port", "hostport", "db.local:99999", true},
+		{"ip v4", "ip", "10.0.0.1", false},
+		{"ip v6", "ip", "::1", false},
+		{"ip invalid", "ip", "10.0.0", true},
+		{"cidr", "cidr", "10.0.0.0/8", false},
+		{"cidr invalid", "cidr", "10.0.0.0", true},
+		{"duration string", "duration", "1h30m", false},
+		{"duration value", "duration", 5 * time.Second, false},
+		{"duration invalid", "duration", "soon", true},
+		{"file_exists", "file_exists", filePath, false},
+		{"file_exists missing", "file_exists", filepath.Join(tmpDir, "missing"), true},
+		{"file_exists directory", "file_exists", tmpDir, true},
+		{"dir_exists", "dir_exists", tmpDir, false},
+		{"dir_exists file", "dir_exists", filePath, true},
+		{"port int", "port", 8080, false},
+		{"port uint16", "port", uint16(443), false},
+		{"port string", "port", "53", false},
+		{"port zero", "port", 0, true},
+		{"port too high", "port", 70000, true},
+		{"port invalid string", "port", "http", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateByRule(tt.rule, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateByRule(%q, %v) error = %v, wantErr %v", tt.rule, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateStruct_CombinedRules(t *testing.T) {
+	type RulesConfig struct {
+		Level    string `config:"default=info,validate=oneof=debug|info|warn"`
+		Name     string `config:"validate=min_len=3;max_len=8;regex=^[a-z]{1,8}$"`
+		Listen   string `config:"validate=hostport"`
+		Optional string `config:"validate=cidr"`
+	}
+
+	tests := []struct {
+		name    string
+		cfg     RulesConfig
+		wantErr bool
+	}{
+		{
+			name: "all valid",
+			cfg:  RulesConfig{Level: "warn", Name: "service", Listen: ":8080"},
+		},
+		{
+			name:    "invalid level",
+			cfg:     RulesConfig{Level: "trace", Name: "service"},
+			wantErr: true,
+		},
+		{
+			name:    "name fails regex",
+			cfg:     RulesConfig{Level: "info", Name: "Service"},
+			wantErr: true,
+		},
+		{
+			name:    "name too long",
+			cfg:     RulesConfig{Level: "info", Name: "servicename"},
+			wantErr: true,
+		},
+		{
+			name:    "empty optional fields are skipped",
+			cfg:     RulesConfig{Level: "info"},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStruct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	RegisterValidation("test_currency", func(value interface{}, param string) error {
+		allowed := "USD|EUR"
+		if param != "" {
+			allowed = param
+		}
+		return validateOneOf(value, allowed)
+	})
+
+	type BillingConfig struct {
+		Currency string `config:"validate=test_currency"`
+		Settle   string `config:"validate=test_currency=GBP"`
+	}
+
+	if err := ValidateStruct(BillingConfig{Currency: "EUR", Settle: "GBP"}); err != nil {
+		t.Errorf("ValidateStruct() error = %v, want nil", err)
+	}
+
+	err := ValidateStruct(BillingConfig{Currency: "JPY", Settle: "USD"})
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("ValidateStruct() error = %v, want 2 validation errors", err)
+	}
+	if errs[0].Field != "Currency" || errs[1].Field != "Settle" {
+		t.Errorf("failing fields = %q, %q, want Currency, Settle", errs[0].Field, errs[1].Field)
+	}
+}
+
+func TestLoader_RegisterValidation(t *testing.T) {
+	type BucketConfig struct {
+		Bucket string `config:"default=My_Bucket,validate=test_s3_bucket"`
+	}
+
+	loader := NewLoader()
+	loader.(ValidationRegistrar).RegisterValidation("test_s3_bucket", func(value interface{}, _ string) error {
+		return validateRegex(value, "^[a-z0-9.-]{3,63}$")
+	})
+
+	var cfg BucketConfig
+	err := loader.Load(&cfg)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || errs[0].Field != "Bucket" || errs[0].Source != "default" {
+		t.Errorf("Load() error = %v, want validation error for Bucket from default", err)
+	}
+
+	// The rule is scoped to the loader that registered it.
+	if err := ValidateStruct(BucketConfig{Bucket: "logs"}); err == nil {
+		t.Error("ValidateStruct() should report unknown rule outside the loader")
+	}
+}
+
+func TestLoader_RegisterValidation_Precedence(t *testing.T) {
+	type PortConfig struct {
+		Port int `config:"validate=port"`
+	}
+
+	loader := NewLoaderWithConfig(Config{ValidateAfterLoad: true})
+	loader.(ValidationRegistrar).RegisterValidation("port", func(value interface{}, _ string) error {
+		return validateRange("1024,65535", value)
+	})
+
+	cfg := PortConfig{Port: 80}
+	if err := loader.Load(&cfg); err == nil {
+		t.Error("Load() should use the loader rule that rejects privileged ports")
+	}
+	if err := ValidateStruct(cfg); err != nil {
+		t.Errorf("ValidateStruct() error = %v, want built-in port rule to accept 80", err)
+	}
+}
+
+func TestRegisterValidation_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleName string
+		fn       ValidationFunc
+	}{
+		{"empty name", "", func(interface{}, string) error { return nil }},
+		{"separator in name", "a;b", func(interface{}, string) error { return nil }},
+		{"nil function", "test_nil", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterValidation() should panic")
+				}
+			}()
+			RegisterValidation(tt.ruleName, tt.fn)
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sampleEntry is a field of a sample configuration file.
+type sampleEntry struct {
+	field   reflect.StructField
+	comment string
+	value   reflect.Value
+	// children holds the fields of a nested struct.
+	children []sampleEntry
+	nested   bool
+}
+
+// Sample returns an example configuration file for v, a struct or pointer to
+// struct, in YAMLFormat or JSONFormat. Every field is listed with its value
+// in v or, if that is zero, its default= value. Sensitive fields are left
+// empty. YAML samples describe each field in a comment built from its desc=,
+// required, default= and validate= tag options.
+//
+// Example:
+//
+//	sample, err := config.Sample(AppConfig{}, config.YAMLFormat)
+//	os.WriteFile("config.example.yaml", sample, 0644)
+func Sample(v interface{}, format Format) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Sample requires a struct or pointer to struct, got %T", v)
+	}
+
+	cfg := reflect.New(rv.Type())
+	cfg.Elem().Set(copyValue(rv))
+	allocateStructs(cfg.Elem(), map[reflect.Type]bool{})
+	if err := NewDefaultSource().Load(cfg.Interface()); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case YAMLFormat:
+		entries := sampleEntries(cfg.Elem(), fileKey)
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(sampleYAML(entries)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case JSONFormat:
+		entries := sampleEntries(cfg.Elem(), jsonKey)
+		var buf bytes.Buffer
+		if err := writeSampleJSON(&buf, entries); err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("Sample supports yaml and json, got %s", format)
+	}
+}
+
+// allocateStructs allocates nil pointers to nested structs, so that their
+// fields appear in the sample. seen stops at recursive types.
+func allocateStructs(rv reflect.Value, seen map[reflect.Type]bool) {
+	seen[rv.Type()] = true
+	defer delete(seen, rv.Type())
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		fieldValue := rv.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		switch {
+		case isNestedStruct(field.Type):
+			allocateStructs(fieldValue, seen)
+		case field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem()) && !seen[field.Type.Elem()]:
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(field.Type.Elem()))
+			}
+			allocateStructs(fieldValue.Elem(), seen)
+		}
+	}
+}
+
+// jsonKey returns the key of a field in JSON configuration files, or "" if
+// the field is not decoded from files.
+func jsonKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(field.Name)
+	default:
+		return name
+	}
+}
+
+// sampleEntries lists the fields of a struct value, named by key.
+func sampleEntries(rv reflect.Value, key func(reflect.StructField) string) []sampleEntry {
+	var entries []sampleEntry
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := key(field)
+		if name == "" {
+			continue
+		}
+		field.Name = name
+
+		entry := sampleEntry{field: field, comment: sampleComment(field), value: fieldValue}
+		switch {
+		case isNestedStruct(field.Type):
+			entry.nested = true
+			entry.children = sampleEntries(fieldValue, key)
+		case field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem()) && !fieldValue.IsNil():
+			entry.nested = true
+			entry.children = sampleEntries(fieldValue.Elem(), key)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// sampleComment describes a field from its tag options, e.g.
+// "HTTP listen port\nrequired, default: 8080, range: 1 to 65535".
+func sampleComment(field reflect.StructField) string {
+	options := parseTagOptions(field.Tag.Get("config"))
+
+	var details []string
+	if _, ok := options["required"]; ok {
+		details = append(details, "required")
+	}
+	sensitive := isSensitiveField(field)
+	if sensitive {
+		details = append(details, "secret")
+	}
+	if def, ok := options["default"]; ok && !sensitive {
+		details = append(details, "default: "+def)
+	}
+	if env := options["env"]; env != "" {
+		details = append(details, "env: "+env)
+	}
+	// Rules following "dive" are about the elements: "each url"
+	each := ""
+	for _, rule := range splitRules(options["validate"]) {
+		name, param := parseRule(rule)
+		switch name {
+		case "":
+		case "dive":
+			each += "each "
+		case "oneof":
+			details = append(details, each+"one of: "+strings.Join(strings.Split(param, "|"), ", "))
+		case "range":
+			min, max, _ := strings.Cut(param, ",")
+			switch {
+			case min == "":
+				details = append(details, each+"at most "+max)
+			case max == "":
+				details = append(details, each+"at least "+min)
+			default:
+				details = append(details, each+"range: "+min+" to "+max)
+			}
+		default:
+			details = append(details, each+strings.TrimSuffix(name+": "+param, ": "))
+		}
+	}
+
+	var lines []string
+	if desc := options["desc"]; desc != "" {
+		lines = append(lines, desc)
+	}
+	if len(details) > 0 {
+		lines = append(lines, strings.Join(details, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sampleValue returns the value to write for a leaf field: empty for
+// sensitive fields, and empty rather than nil slices and maps.
+func sampleValue(entry sampleEntry) interface{} {
+	v := entry.value
+	if isSensitiveField(entry.field) {
+		if v.Kind() == reflect.String {
+			return ""
+		}
+		return reflect.Zero(v.Type()).Interface()
+	}
+	switch {
+	case v.Kind() == reflect.Slice && v.IsNil():
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	case v.Kind() == reflect.Map && v.IsNil():
+		return reflect.MakeMap(v.Type()).Interface()
+	}
+	return v.Interface()
+}
+
+// sampleYAML builds the YAML mapping of entries, with a comment above each key.
+func sampleYAML(entries []sampleEntry) *yaml.Node {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, entry := range entries {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: entry.field.Name, HeadComment: entry.comment}
+
+		var value *yaml.Node
+		if entry.nested {
+			value = sampleYAML(entry.children)
+		} else {
+			value = &yaml.Node{}
+			if err := value.Encode(sampleValue(entry)); err != nil {
+				value = &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(sampleValue(entry))}
+			}
+		}
+		mapping.Content = append(mapping.Content, key, value)
+	}
+	return mapping
+}
+
+// writeSampleJSON writes the JSON object of entries, in field order.
+func writeSampleJSON(buf *bytes.Buffer, entries []sampleEntry) error {
+	buf.WriteByte('{')
+	for i, entry := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.field.Name)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		if entry.nested {
+			if err := writeSampleJSON(buf, entry.children); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := json.Marshal(sampleValue(entry))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", entry.field.Name, err)
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaDialect is the JSON Schema version emitted by JSONSchema.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the values accepted by time.ParseDuration.
+const durationPattern = `^[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// unitDurationPattern matches the values accepted by ParseDuration.
+const unitDurationPattern = `^[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h|d|w))+$|^0$`
+
+var (
+	// timeType is the reflect.Type of time.Time.
+	timeType = reflect.TypeOf(time.Time{})
+
+	byteSizeType     = reflect.TypeOf(ByteSize(0))
+	percentType      = reflect.TypeOf(Percent(0))
+	unitDurationType = reflect.TypeOf(Duration(0))
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the
+// configuration files accepted for v, a struct or pointer to struct.
+//
+// Properties are named as in YAML files: by their yaml tag or their
+// lower-cased field name. The config tag options are mapped to schema
+// keywords: default= to default, required to the object's required list,
+// desc= to description, and the validate= rules oneof, range, port, min_len,
+// max_len, regex, email and url to enum, minimum/maximum, minLength/maxLength
+// (minItems/maxItems for slices), pattern and format. Sensitive fields are
+// marked writeOnly and their defaults are left out.
+//
+// Example:
+//
+//	schema, err := config.JSONSchema(AppConfig{})
+//	os.WriteFile("config.schema.json", schema, 0644)
+func JSONSchema(v interface{}) ([]byte, error) {
+	rt := reflect.TypeOf(v)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("JSONSchema requires a struct or pointer to struct, got %T", v)
+	}
+
+	schema := structSchema(rt, map[reflect.Type]bool{})
+	schema["$schema"] = schemaDialect
+	if rt.Name() != "" {
+		schema["title"] = rt.Name()
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// structSchema returns the object schema of struct type rt. seen holds the
+// struct types being described, to stop at recursive types.
+func structSchema(rt reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	seen[rt] = true
+	defer delete(seen, rt)
+
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fileKey(field)
+		if name == "" {
+			continue
+		}
+
+		options := parseTagOptions(field.Tag.Get("config"))
+		properties[name] = fieldSchema(field, options, seen)
+		if _, ok := options["required"]; ok {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fileKey returns the key of a field in YAML configuration files, or "" if
+// the field is not decoded from files.
+func fileKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(field.Name)
+	default:
+		return name
+	}
+}
+
+// fieldSchema returns the schema of a struct field, annotated from its tag options.
+func fieldSchema(field reflect.StructField, options map[string]string, seen map[reflect.Type]bool) map[string]interface{} {
+	schema := typeSchema(field.Type, seen)
+
+	if desc := options["desc"]; desc != "" {
+		schema["description"] = desc
+	}
+	if _, ok := options["deprecated"]; ok {
+		schema["deprecated"] = true
+	}
+	sensitive := isSensitiveField(field)
+	if sensitive {
+		schema["writeOnly"] = true
+	}
+	if def, ok := options["default"]; ok && !sensitive {
+		schema["default"] = schemaValue(field.Type, def)
+	}
+
+	rules := options["validate"]
+	if rules == "" {
+		return schema
+	}
+	valueRules, elementRules, dive := splitDive(splitRules(rules))
+	for _, rule := range valueRules {
+		name, param := parseRule(rule)
+		applyRule(schema, field.Type, name, param)
+	}
+	if dive {
+		applyElementRules(schema, field.Type, elementRules)
+	}
+	return schema
+}
+
+// applyElementRules adds the keywords of the rules following "dive" to the
+// items schema of a slice or the value schema of a map.
+func applyElementRules(schema map[string]interface{}, t reflect.Type, rules []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var element map[string]interface{}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		element, _ = schema["items"].(map[string]interface{})
+	case reflect.Map:
+		element, _ = schema["additionalProperties"].(map[string]interface{})
+	}
+	if element == nil {
+		return
+	}
+
+	valueRules, elementRules, dive := splitDive(rules)
+	for _, rule := range valueRules {
+		name, param := parseRule(rule)
+		applyRule(element, t.Elem(), name, param)
+	}
+	if dive {
+		applyElementRules(element, t.Elem(), elementRules)
+	}
+}
+
+// applyRule adds the schema keywords equivalent to a validation rule.
+// Rules without an equivalent, such as custom rules, are skipped.
+func applyRule(schema map[string]interface{}, t reflect.Type, name, param string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch name {
+	case "oneof":
+		var values []interface{}
+		for _, option := range strings.Split(param, "|") {
+			values = append(values, schemaValue(t, strings.TrimSpace(option)))
+		}
+		schema["enum"] = values
+	case "range":
+		if min, max, err := parseTypedRangeValues(param, t); err == nil {
+			if min != nil {
+				schema["minimum"] = *min
+			}
+			if max != nil {
+				schema["maximum"] = *max
+			}
+		}
+	case "port":
+		if isNumberKind(t.Kind()) {
+			schema["minimum"] = 1
+			schema["maximum"] = 65535
+		}
+	case "min_len", "max_len":
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		keyword := map[reflect.Kind]string{
+			reflect.String: "Length",
+			reflect.Slice:  "Items",
+			reflect.Array:  "Items",
+			reflect.Map:    "Properties",
+		}[t.Kind()]
+		if keyword == "" {
+			return
+		}
+		if name == "min_len" {
+			schema["min"+keyword] = limit
+		} else {
+			schema["max"+keyword] = limit
+		}
+	case "regex":
+		schema["pattern"] = param
+	case "email":
+		schema["format"] = "email"
+	case "url":
+		schema["format"] = "uri"
+	case "duration":
+		schema["pattern"] = durationPattern
+	}
+}
+
+// typeSchema returns the schema of a Go type as it appears in configuration files.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]interface{}{"type": "string", "pattern": durationPattern}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == urlType:
+		return map[string]interface{}{"type": "string", "format": "uri"}
+	case t == byteSizeType:
+		return map[string]interface{}{"type": []string{"integer", "string"}}
+	case t == percentType:
+		return map[string]interface{}{"type": []string{"number", "string"}}
+	case t == unitDurationType:
+		return map[string]interface{}{"type": "string", "pattern": unitDurationPattern}
+	case isTextUnmarshaler(t):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		return structSchema(t, seen)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// isNumberKind reports whether k is an integer or floating-point kind.
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// schemaValue converts a tag value to the JSON value of a field of type t,
+// using the same conversion as defaults. Values that do not convert, and
+// values of types written as strings in files, are kept as strings.
+func schemaValue(t reflect.Type, value string) interface{} {
+	rv := reflect.New(t).Elem()
+	if err := setFieldValue(rv, value); err != nil {
+		return value
+	}
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Type() == durationType || rv.Type() == urlType {
+		return value
+	}
+	if marshaler, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		if text, err := marshaler.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return rv.Interface()
+}
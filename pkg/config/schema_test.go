@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaConfig struct {
+	Name     string        `config:"required,desc='Service name'"`
+	Port     int           `config:"default=8080,validate=range=1,65535,desc='HTTP listen port'"`
+	Level    string        `config:"default=info,validate=oneof=debug|info|warn"`
+	Timeout  time.Duration `config:"default=30s"`
+	Ratio    float64       `config:"validate=range=0,"`
+	Hosts    []string      `config:"default=a;b,validate=min_len=1"`
+	Email    string        `config:"validate=email"`
+	Code     string        `config:"validate=regex=^[A-Z]+$;max_len=8"`
+	Token    string        `config:"default=dev-token"`
+	Renamed  string        `yaml:"other_name"`
+	Skipped  string        `yaml:"-"`
+	Labels   map[string]int
+	Mirrors  []string `config:"validate=max_len=3;dive;url"`
+	internal string
+	Database *struct {
+		Host string `config:"env=DB_HOST,default=localhost,required"`
+	}
+	Next *schemaConfig
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema(&schemaConfig{})
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("JSONSchema() returned invalid JSON: %v", err)
+	}
+
+	if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" || schema["title"] != "schemaConfig" {
+		t.Errorf("schema header = %v, %v", schema["$schema"], schema["title"])
+	}
+	if !reflect.DeepEqual(schema["required"], []interface{}{"name"}) {
+		t.Errorf("required = %v, want [name]", schema["required"])
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"skipped", "internal", "renamed"} {
+		if _, ok := properties[name]; ok {
+			t.Errorf("properties should not contain %q", name)
+		}
+	}
+
+	tests := []struct {
+		property string
+		want     map[string]interface{}
+	}{
+		{"name", map[string]interface{}{"type": "string", "description": "Service name"}},
+		{"port", map[string]interface{}{"type": "integer", "default": 8080.0, "minimum": 1.0, "maximum": 65535.0, "description": "HTTP listen port"}},
+		{"level", map[string]interface{}{"type": "string", "default": "info", "enum": []interface{}{"debug", "info", "warn"}}},
+		{"timeout", map[string]interface{}{"type": "string", "default": "30s", "pattern": durationPattern}},
+		{"ratio", map[string]interface{}{"type": "number", "minimum": 0.0}},
+		{"hosts", map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "default": []interface{}{"a", "b"}, "minItems": 1.0}},
+		{"email", map[string]interface{}{"type": "string", "format": "email"}},
+		{"code", map[string]interface{}{"type": "string", "pattern": "^[A-Z]+$", "maxLength": 8.0}},
+		{"token", map[string]interface{}{"type": "string", "writeOnly": true}},
+		{"other_name", map[string]interface{}{"type": "string"}},
+		{"labels", map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}}},
+		{"mirrors", map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "format": "uri"}, "maxItems": 3.0}},
+		{"next", map[string]interface{}{"type": "object"}},
+	}
+	for _, tt := range tests {
+		if got := properties[tt.property]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("properties[%s] = %v, want %v", tt.property, got, tt.want)
+		}
+	}
+
+	database := properties["database"].(map[string]interface{})
+	if !reflect.DeepEqual(database["required"], []interface{}{"host"}) || database["additionalProperties"] != false {
+		t.Errorf("properties[database] = %v, want nested object with required host", database)
+	}
+
+	if _, err := JSONSchema("not a struct"); err == nil {
+		t.Error("JSONSchema() should fail for a non-struct")
+	}
+}
+
+func TestSample(t *testing.T) {
+	type sampleConfig struct {
+		Name     string        `config:"required,desc='Service name'"`
+		Port     int           `config:"default=8080,validate=range=1,65535"`
+		Level    string        `config:"validate=oneof=debug|info"`
+		Timeout  time.Duration `config:"default=30s"`
+		Hosts    []string
+		Password string `config:"default=changeme"`
+		Database *struct {
+			Host string `config:"env=DB_HOST,default=localhost"`
+		}
+	}
+
+	yamlSample, err := Sample(sampleConfig{Name: "billing"}, YAMLFormat)
+	if err != nil {
+		t.Fatalf("Sample(yaml) error = %v", err)
+	}
+	wantYAML := `# Service name
+# required
+name: billing
+# default: 8080, range: 1 to 65535
+port: 8080
+# one of: debug, info
+level: ""
+# default: 30s
+timeout: 30s
+hosts: []
+# secret
+password: ""
+database:
+  # default: localhost, env: DB_HOST
+  host: localhost
+`
+	if string(yamlSample) != wantYAML {
+		t.Errorf("Sample(yaml) =\n%s\nwant\n%s", yamlSample, wantYAML)
+	}
+
+	// The YAML sample loads back into the struct
+	var decoded sampleConfig
+	if err := decodeData("sample.yaml", yamlSample, &decoded, true, nil, nil); err != nil {
+		t.Errorf("decoding the YAML sample failed: %v", err)
+	}
+
+	jsonSample, err := Sample(&sampleConfig{}, JSONFormat)
+	if err != nil {
+		t.Fatalf("Sample(json) error = %v", err)
+	}
+	if !strings.HasPrefix(string(jsonSample), "{\n  \"name\": \"\",\n  \"port\": 8080,") {
+		t.Errorf("Sample(json) = %s, want fields in declaration order", jsonSample)
+	}
+	if err := decodeData("sample.json", jsonSample, &decoded, true, nil, nil); err != nil {
+		t.Errorf("decoding the JSON sample failed: %v", err)
+	}
+
+	if _, err := Sample(sampleConfig{}, TOMLFormat); err == nil {
+		t.Error("Sample() should fail for unsupported formats")
+	}
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maskedValue replaces the value of sensitive fields in reports and dumps.
+const maskedValue = "******"
+
+// sensitiveNameParts are lower-case fragments of field names whose values are
+// treated as sensitive even without the secret tag option.
+var sensitiveNameParts = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"privatekey",
+	"credential",
+}
+
+// Secret is a string that hides its value when printed or serialized, so
+// that logging a config struct does not leak it. Use Value to read it.
+//
+// Example:
+//
+//	type DatabaseConfig struct {
+//		Password config.Secret `config:"required"`
+//	}
+//
+//	db.Connect(cfg.Database.Password.Value())
+type Secret string
+
+// Value returns the secret in plain text.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer, masking the value.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return maskedValue
+}
+
+// GoString implements fmt.GoStringer, masking the value in %#v output.
+func (s Secret) GoString() string {
+	return fmt.Sprintf("config.Secret(%q)", s.String())
+}
+
+// MarshalJSON implements json.Marshaler, masking the value.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML implements yaml.Marshaler, masking the value.
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// secretType is the reflect.Type of Secret.
+var secretType = reflect.TypeOf(Secret(""))
+
+// Redact returns a deep copy of cfg in which the values of sensitive fields
+// are masked: strings become "******" and other types are zeroed. Structs in
+// slices, arrays and maps are masked too. cfg may be a struct or a pointer to
+// a struct; the result has the same type.
+//
+// A field is sensitive if it has the secret tag option, has type Secret, or
+// its name contains password, passwd, secret, token, apikey, privatekey or
+// credential.
+//
+// Example:
+//
+//	type DatabaseConfig struct {
+//		Host string
+//		DSN  string `config:"secret"`
+//	}
+//
+//	log.Printf("%+v", config.Redact(cfg))
+func Redact(cfg interface{}) interface{} {
+	rv := reflect.ValueOf(cfg)
+	if !rv.IsValid() {
+		return cfg
+	}
+
+	redacted := copyValue(rv)
+	target := redacted
+	for target.Kind() == reflect.Ptr && !target.IsNil() {
+		target = target.Elem()
+	}
+	if target.Kind() == reflect.Struct {
+		redactStruct(target)
+	}
+	return redacted.Interface()
+}
+
+// Dump returns an indented JSON rendering of cfg with sensitive fields
+// masked (see Redact). It is intended for logging the effective configuration.
+func Dump(cfg interface{}) string {
+	redacted := Redact(cfg)
+	data, err := json.MarshalIndent(redacted, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%+v", redacted)
+	}
+	return string(data)
+}
+
+// redactStruct masks the sensitive fields of an addressable struct value in place.
+func redactStruct(rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		if !field.IsExported() || !fieldValue.CanSet() {
+			continue
+		}
+
+		if isSensitiveField(field) {
+			maskValue(fieldValue)
+			continue
+		}
+		redactNested(fieldValue)
+	}
+}
+
+// redactNested masks the sensitive fields of the nested structs held by a
+// value: the value itself, the target of a non-nil pointer, or the elements
+// of a slice, array or map. rv must be addressable.
+func redactNested(rv reflect.Value) {
+	if !hasNestedStruct(rv.Type()) {
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		redactStruct(rv)
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			redactNested(rv.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			redactNested(rv.Index(i))
+		}
+	case reflect.Map:
+		// Map values are not addressable; mask a copy and store it back.
+		// The map belongs to the copy made by Redact.
+		for _, key := range rv.MapKeys() {
+			value := reflect.New(rv.Type().Elem()).Elem()
+			value.Set(rv.MapIndex(key))
+			redactNested(value)
+			rv.SetMapIndex(key, value)
+		}
+	}
+}
+
+// maskValue replaces a non-empty value: strings (and pointers to strings)
+// become "******", anything else is zeroed.
+func maskValue(v reflect.Value) {
+	if isZeroValue(v) {
+		return
+	}
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.String {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(maskedValue)
+		return
+	}
+	v.Set(reflect.Zero(v.Type()))
+}
+
+// isSensitiveField reports whether a field's value must be masked in reports,
+// dumps and validation errors.
+func isSensitiveField(field reflect.StructField) bool {
+	if field.Type == secretType {
+		return true
+	}
+	if _, ok := parseTagOptions(field.Tag.Get("config"))["secret"]; ok {
+		return true
+	}
+
+	name := strings.ToLower(strings.ReplaceAll(field.Name, "_", ""))
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
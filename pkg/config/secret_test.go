@@ -0,0 +1,283 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSecret_Masking(t *testing.T) {
+	secret := Secret("hunter2")
+
+	if secret.Value() != "hunter2" {
+		t.Errorf("Value() = %q, want %q", secret.Value(), "hunter2")
+	}
+
+	outputs := map[string]string{
+		"%v":  fmt.Sprintf("%v", secret),
+		"%s":  fmt.Sprintf("%s", secret),
+		"%+v": fmt.Sprintf("%+v", struct{ Password Secret }{secret}),
+		"%#v": fmt.Sprintf("%#v", secret),
+	}
+
+	data, err := json.Marshal(struct{ Password Secret }{secret})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	outputs["json"] = string(data)
+
+	data, err = yaml.Marshal(struct{ Password Secret }{secret})
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+	outputs["yaml"] = string(data)
+
+	for format, output := range outputs {
+		if strings.Contains(output, "hunter2") || !strings.Contains(output, maskedValue) {
+			t.Errorf("%s output = %q, want masked value", format, output)
+		}
+	}
+
+	if Secret("").String() != "" {
+		t.Errorf("empty Secret should print as empty string")
+	}
+}
+
+func TestSecret_Load(t *testing.T) {
+	type SecretConfig struct {
+		Password Secret `config:"required"`
+	}
+
+	os.Setenv("SECRET_PASSWORD", "hunter2")
+	defer os.Unsetenv("SECRET_PASSWORD")
+
+	var cfg SecretConfig
+	loader := NewLoaderWithConfig(Config{EnvPrefix: "SECRET", ValidateAfterLoad: true})
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Password.Value() != "hunter2" {
+		t.Errorf("cfg.Password.Value() = %q, want %q", cfg.Password.Value(), "hunter2")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	type Database struct {
+		Host     string
+		DSN      string `config:"secret"`
+		Password *string
+		Pin      int `config:"secret"`
+		Token    Secret
+	}
+	type RedactConfig struct {
+		Name     string
+		Database Database
+		Replica  *Database
+		APIKey   string `config:"secret,env=API_KEY"`
+	}
+
+	password := "hunter2"
+	cfg := RedactConfig{
+		Name:     "app",
+		Database: Database{Host: "db.local", DSN: "postgres://u:p@db", Password: &password, Pin: 1234, Token: "t0k"},
+		Replica:  &Database{Host: "replica.local", DSN: "postgres://u:p@replica"},
+		APIKey:   "key",
+	}
+
+	redacted, ok := Redact(cfg).(RedactConfig)
+	if !ok {
+		t.Fatalf("Redact() returned %T, want RedactConfig", Redact(cfg))
+	}
+
+	if redacted.Name != "app" || redacted.Database.Host != "db.local" || redacted.Replica.Host != "replica.local" {
+		t.Errorf("Redact() changed non-sensitive fields: %+v", redacted)
+	}
+	if redacted.Database.DSN != maskedValue || redacted.Replica.DSN != maskedValue || redacted.APIKey != maskedValue {
+		t.Errorf("Redact() did not mask tagged strings: %+v", redacted)
+	}
+	if *redacted.Database.Password != maskedValue {
+		t.Errorf("Redact() Password = %q, want %q", *redacted.Database.Password, maskedValue)
+	}
+	if redacted.Database.Pin != 0 {
+		t.Errorf("Redact() Pin = %d, want 0", redacted.Database.Pin)
+	}
+	if redacted.Database.Token.Value() != maskedValue {
+		t.Errorf("Redact() Token = %q, want %q", redacted.Database.Token.Value(), maskedValue)
+	}
+	if redacted.Replica.Password != nil {
+		t.Error("Redact() should leave nil pointers nil")
+	}
+
+	// The original is untouched
+	if cfg.Database.DSN != "postgres://u:p@db" || password != "hunter2" || cfg.Replica.DSN != "postgres://u:p@replica" {
+		t.Errorf("Redact() modified its input: %+v", cfg)
+	}
+
+	// Pointers in, pointers out
+	if _, ok := Redact(&cfg).(*RedactConfig); !ok {
+		t.Errorf("Redact(&cfg) returned %T, want *RedactConfig", Redact(&cfg))
+	}
+}
+
+func TestRedact_Collections(t *testing.T) {
+	type Upstream struct {
+		Host string
+		Key  string `config:"secret"`
+	}
+	type CollectionConfig struct {
+		Upstreams []Upstream
+		Backends  map[string]Upstream
+		Replicas  []*Upstream
+		Fixed     [1]Upstream
+	}
+
+	cfg := CollectionConfig{
+		Upstreams: []Upstream{{Host: "a.local", Key: "hunter2"}},
+		Backends:  map[string]Upstream{"primary": {Host: "b.local", Key: "hunter2"}},
+		Replicas:  []*Upstream{{Host: "c.local", Key: "hunter2"}, nil},
+		Fixed:     [1]Upstream{{Host: "d.local", Key: "hunter2"}},
+	}
+
+	redacted := Redact(cfg).(CollectionConfig)
+	if redacted.Upstreams[0].Key != maskedValue || redacted.Backends["primary"].Key != maskedValue ||
+		redacted.Replicas[0].Key != maskedValue || redacted.Fixed[0].Key != maskedValue {
+		t.Errorf("Redact() did not mask secrets in collections: %+v", redacted)
+	}
+	if redacted.Upstreams[0].Host != "a.local" || redacted.Backends["primary"].Host != "b.local" {
+		t.Errorf("Redact() changed non-sensitive fields: %+v", redacted)
+	}
+
+	// The original is untouched
+	if cfg.Upstreams[0].Key != "hunter2" || cfg.Backends["primary"].Key != "hunter2" || cfg.Replicas[0].Key != "hunter2" {
+		t.Errorf("Redact() modified its input: %+v", cfg)
+	}
+
+	if dump := Dump(cfg); strings.Contains(dump, "hunter2") {
+		t.Errorf("Dump() leaked a secret: %s", dump)
+	}
+}
+
+func TestDump(t *testing.T) {
+	type DumpConfig struct {
+		Host     string `json:"host"`
+		Password string `json:"password"`
+	}
+
+	dump := Dump(&DumpConfig{Host: "db.local", Password: "hunter2"})
+	if strings.Contains(dump, "hunter2") {
+		t.Errorf("Dump() leaked a secret: %s", dump)
+	}
+	if !strings.Contains(dump, `"host": "db.local"`) || !strings.Contains(dump, `"password": "******"`) {
+		t.Errorf("Dump() = %s, want host and masked password", dump)
+	}
+}
+
+func TestEnvSource_FileSuffix(t *testing.T) {
+	type FileSecretConfig struct {
+		Database struct {
+			Password string
+			User     string
+		}
+	}
+
+	tmpDir := t.TempDir()
+	secretPath := filepath.Join(tmpDir, "db_password")
+	if err := os.WriteFile(secretPath, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatalf("Failed to create secret file: %v", err)
+	}
+
+	os.Setenv("FS_DATABASE_PASSWORD_FILE", secretPath)
+	os.Setenv("FS_DATABASE_USER", "admin")
+	defer os.Unsetenv("FS_DATABASE_PASSWORD_FILE")
+	defer os.Unsetenv("FS_DATABASE_USER")
+
+	loader := NewLoaderWithConfig(Config{EnvPrefix: "FS"})
+
+	var cfg FileSecretConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.Password != "s3cret" {
+		t.Errorf("cfg.Database.Password = %q, want %q", cfg.Database.Password, "s3cret")
+	}
+	if cfg.Database.User != "admin" {
+		t.Errorf("cfg.Database.User = %q, want %q", cfg.Database.User, "admin")
+	}
+
+	origin := loader.(ProvenanceReporter).Provenance()["Database.Password"]
+	if origin.String() != "env:FS_DATABASE_PASSWORD_FILE" {
+		t.Errorf("Provenance()[Database.Password] = %q, want %q", origin, "env:FS_DATABASE_PASSWORD_FILE")
+	}
+
+	// Setting both the variable and its _FILE form is ambiguous
+	os.Setenv("FS_DATABASE_PASSWORD", "other")
+	defer os.Unsetenv("FS_DATABASE_PASSWORD")
+	if err := NewEnvSource("FS").Load(&cfg); err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("Load() error = %v, want error about both variables being set", err)
+	}
+	os.Unsetenv("FS_DATABASE_PASSWORD")
+
+	// A missing file is an error, not a silently empty value
+	os.Setenv("FS_DATABASE_PASSWORD_FILE", filepath.Join(tmpDir, "missing"))
+	if err := NewEnvSource("FS").Load(&cfg); err == nil {
+		t.Error("Load() should fail when the _FILE path does not exist")
+	}
+}
+
+func TestValidateStruct_MasksSecretValues(t *testing.T) {
+	type MaskedConfig struct {
+		Password string `config:"validate=min_len=12"`
+		Token    string `config:"secret,validate=regex=^tk_"`
+		Name     string `config:"validate=min_len=12"`
+	}
+
+	err := ValidateStruct(MaskedConfig{Password: "hunter2", Token: "abc", Name: "short"})
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("ValidateStruct() error = %v, want 3 validation errors", err)
+	}
+	if strings.Contains(err.Error(), "hunter2") || strings.Contains(err.Error(), "abc") {
+		t.Errorf("ValidateStruct() error leaked a secret: %v", err)
+	}
+	if errs[2].Value != "short" {
+		t.Errorf("non-sensitive value = %v, want %q", errs[2].Value, "short")
+	}
+}
+
+func TestValidateStruct_MasksSecretStructs(t *testing.T) {
+	type Credentials struct {
+		User string `config:"validate=min_len=10"`
+		Pass string `config:"validate=min_len=10"`
+	}
+	type ParentConfig struct {
+		DB      Credentials  `config:"secret"`
+		Replica *Credentials `config:"secret"`
+		Admin   Credentials
+	}
+
+	err := ValidateStruct(ParentConfig{
+		DB:      Credentials{User: "dbuser", Pass: "hunter2"},
+		Replica: &Credentials{User: "replica", Pass: "hunter3"},
+		Admin:   Credentials{User: "root", Pass: "correcthorsebattery"},
+	})
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 5 {
+		t.Fatalf("ValidateStruct() error = %v, want 5 validation errors", err)
+	}
+	for _, secret := range []string{"dbuser", "hunter2", "replica", "hunter3"} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("ValidateStruct() error leaked %q: %v", secret, err)
+		}
+	}
+	if errs[4].Field != "Admin.User" || errs[4].Value != "root" {
+		t.Errorf("errs[4] = %+v, want Admin.User with its value", errs[4])
+	}
+}
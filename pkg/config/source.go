@@ -1,10 +1,11 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -14,8 +15,19 @@ type Source interface {
 }
 
 // FileSource loads configuration from a file.
+//
+// YAML files may include other files with the !include tag and JSON files
+// may reference values in other files with $ref, as for DecodeFile. Fields
+// set from an included file are attributed to it in Provenance, and Watch
+// polls the included files too.
 type FileSource struct {
 	Path string
+	// Interpolate expands ${VAR}, ${VAR:-default}, ${VAR:?message} and
+	// ${file:path} references in the values of the file once parsed.
+	Interpolate bool
+	// Strict makes keys that match no field an error. All unknown keys are
+	// reported at once, each with its line and column.
+	Strict bool
 }
 
 // NewFileSource creates a new file source.
@@ -23,22 +35,93 @@ func NewFileSource(path string) *FileSource {
 	return &FileSource{Path: path}
 }
 
+// Origin identifies the file in Provenance.
+func (s *FileSource) Origin() Origin {
+	return Origin{Source: "file", Key: s.Path}
+}
+
+// fingerprint writes the paths and content of the file and of the files it
+// includes for Watch.
+func (s *FileSource) fingerprint(w io.Writer) error {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s\x00%d\x00", s.Path, len(data))
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	inc := s.includer()
+	inc.scan(DetectFormat(s.Path), data)
+	return inc.fingerprint(w)
+}
+
+// includer returns the includer for the file, which interpolates included
+// files as the file itself is.
+func (s *FileSource) includer() *includer {
+	inc := newFileIncluder(s.Path, os.ReadFile)
+	inc.interpolate = s.Interpolate
+	return inc
+}
+
 // Load loads configuration from a file.
 func (s *FileSource) Load(cfg interface{}) error {
+	_, err := s.loadReport(context.Background(), cfg)
+	return err
+}
+
+// loadReport loads the file and reports the file, or included file, each
+// field was read from and the deprecated keys used.
+func (s *FileSource) loadReport(_ context.Context, cfg interface{}) (loadReport, error) {
+	var report loadReport
 	if s.Path == "" {
-		return nil // No file specified, skip
+		return report, nil // No file specified, skip
 	}
 
 	if _, err := os.Stat(s.Path); os.IsNotExist(err) {
-		return fmt.Errorf("file not found: %s", s.Path)
+		return report, fmt.Errorf("file not found: %s", s.Path)
 	}
 
-	return DecodeFile(s.Path, cfg)
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return report, fmt.Errorf("open file: %w", err)
+	}
+
+	inc := s.includer()
+	if err := decodeData(s.Path, data, cfg, s.Strict, func(w Warning) { report.warns = append(report.warns, w) }, inc); err != nil {
+		return report, err
+	}
+	report.keys = inc.fieldKeys(reflect.TypeOf(cfg), DetectFormat(s.Path), data, s.Path)
+	return report, nil
 }
 
 // EnvSource loads configuration from environment variables.
+//
+// Fields without an explicit env= tag are read from a key built from their
+// path: the prefix, the names of the enclosing struct fields and the field
+// name, upper-cased and joined by Separator (Database.Host with prefix APP
+// becomes APP_DATABASE_HOST). Explicit env= keys are used as-is, prepended
+// only by the prefix.
+//
+// If KEY is not set but KEY_FILE is, the value is read from the file KEY_FILE
+// names, as with secrets mounted by Docker and Kubernetes
+// (APP_DATABASE_PASSWORD_FILE=/run/secrets/db). Setting both is an error.
+//
+// Old names given with the alias= tag option are read like env= keys, with
+// dots replaced by Separator: alias=db_host reads APP_DB_HOST. Using an old
+// name is reported as a Warning, and setting both an old and the new name
+// to different values is an error.
 type EnvSource struct {
 	Prefix string
+	// Separator joins the prefix and the path segments (default: "_").
+	Separator string
+	// FlatNames enables the legacy naming scheme, in which nested fields ignore
+	// the names of their parents (Database.Host is read from APP_HOST).
+	FlatNames bool
+
+	// read looks up keys in place of the environment, for DirSource.
+	read func(key string) (value, sourceKey string, err error)
 }
 
 // NewEnvSource creates a new environment variable source.
@@ -46,13 +129,35 @@ func NewEnvSource(prefix string) *EnvSource {
 	return &EnvSource{Prefix: prefix}
 }
 
+// Origin identifies the environment in Provenance. The variable each field
+// was read from is recorded as the origin's Key.
+func (s *EnvSource) Origin() Origin {
+	return Origin{Source: "env"}
+}
+
 // Load loads configuration from environment variables.
 func (s *EnvSource) Load(cfg interface{}) error {
-	return s.loadStruct(cfg, "")
+	_, err := s.loadReport(context.Background(), cfg)
+	return err
 }
 
-// loadStruct recursively loads environment variables into a struct.
-func (s *EnvSource) loadStruct(cfg interface{}, prefix string) error {
+// loadReport loads environment variables and reports the variable each
+// field was read from and the deprecated variables used.
+func (s *EnvSource) loadReport(_ context.Context, cfg interface{}) (loadReport, error) {
+	if err := checkConfigPointer(cfg); err != nil {
+		return loadReport{}, err
+	}
+	report := loadReport{keys: make(map[string]string)}
+	err := s.loadStruct(cfg, "", "", make(map[reflect.Type]bool), &report)
+	return report, err
+}
+
+// loadStruct recursively loads environment variables into a struct,
+// recording the variables read in report.
+// prefix is the environment key of the enclosing struct field and path its
+// dotted field path; both are empty at the top level. seen holds the struct
+// types being loaded, so that recursive types are not followed forever.
+func (s *EnvSource) loadStruct(cfg interface{}, prefix, path string, seen map[reflect.Type]bool, report *loadReport) error {
 	rv := reflect.ValueOf(cfg)
 	if rv.Kind() == reflect.Ptr {
 		rv = rv.Elem()
@@ -63,6 +168,8 @@ func (s *EnvSource) loadStruct(cfg interface{}, prefix string) error {
 	}
 
 	rt := rv.Type()
+	seen[rt] = true
+	defer delete(seen, rt)
 	for i := 0; i < rv.NumField(); i++ {
 		field := rt.Field(i)
 		fieldValue := rv.Field(i)
@@ -77,10 +184,24 @@ func (s *EnvSource) loadStruct(cfg interface{}, prefix string) error {
 
 		// Get environment variable name
 		envKey := s.getEnvKey(field, prefix, options)
+		fieldPath := joinPath(path, field.Name)
 
 		// Handle nested structs
-		if fieldValue.Kind() == reflect.Struct {
-			if err := s.loadStruct(fieldValue.Addr().Interface(), envKey); err != nil {
+		if isNestedStruct(fieldValue.Type()) {
+			if err := s.loadStruct(fieldValue.Addr().Interface(), envKey, fieldPath, seen, report); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Handle pointers to nested structs, allocating them only if one of
+		// their fields is set from the environment. Pointers to a struct
+		// being loaded are skipped.
+		if fieldValue.Kind() == reflect.Ptr && isNestedStruct(fieldValue.Type().Elem()) {
+			if seen[fieldValue.Type().Elem()] {
+				continue
+			}
+			if err := s.loadStructPtr(fieldValue, envKey, fieldPath, seen, report); err != nil {
 				return err
 			}
 			continue
@@ -91,42 +212,135 @@ func (s *EnvSource) loadStruct(cfg interface{}, prefix string) error {
 			continue
 		}
 
-		// Get value from environment
-		envValue := os.Getenv(envKey)
+		// Get value from environment, or from the file named by KEY_FILE
+		read := s.lookup
+		if s.read != nil {
+			read = s.read
+		}
+		envValue, sourceKey, err := read(envKey)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		if message, deprecated := options["deprecated"]; deprecated && envValue != "" {
+			report.warns = append(report.warns, Warning{Field: fieldPath, Key: sourceKey, Message: deprecationMessage(sourceKey, message)})
+		}
+
+		// Fall back to the old names of the field
+		for _, alias := range splitAliases(options["alias"]) {
+			aliasValue, aliasKey, err := read(s.aliasKey(alias))
+			if err != nil {
+				return fmt.Errorf("field %q: %w", field.Name, err)
+			}
+			if aliasValue == "" {
+				continue
+			}
+			report.warns = append(report.warns, Warning{Field: fieldPath, Key: aliasKey, Message: fmt.Sprintf("%s is deprecated, use %s", aliasKey, envKey)})
+			if envValue == "" {
+				envValue, sourceKey = aliasValue, aliasKey
+			} else if aliasValue != envValue {
+				return fmt.Errorf("field %q: both %s and %s are set with different values", field.Name, sourceKey, aliasKey)
+			}
+		}
 		if envValue == "" {
 			continue // No env var set, skip
 		}
 
 		// Set the field value
-		if err := s.setFieldValue(fieldValue, envValue); err != nil {
+		if err := setFieldValue(fieldValue, envValue); err != nil {
 			return fmt.Errorf("field %q: %w", field.Name, err)
 		}
+		report.keys[fieldPath] = sourceKey
 	}
 
 	return nil
 }
 
+// fileKeySuffix marks an environment variable holding the path of a file
+// that contains the value.
+const fileKeySuffix = "_FILE"
+
+// lookup returns the value for envKey and the variable it was read from:
+// envKey itself, or envKey+"_FILE" when the value is read from a file.
+// Trailing newlines in the file are removed.
+func (s *EnvSource) lookup(envKey string) (value, sourceKey string, err error) {
+	value = os.Getenv(envKey)
+	fileKey := envKey + fileKeySuffix
+	path := os.Getenv(fileKey)
+
+	if path == "" {
+		return value, envKey, nil
+	}
+	if value != "" {
+		return "", "", fmt.Errorf("both %s and %s are set", envKey, fileKey)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("read %s: %w", fileKey, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), fileKey, nil
+}
+
+// loadStructPtr loads environment variables into a pointer to a nested struct.
+// A nil pointer is only allocated if at least one of its fields is set.
+func (s *EnvSource) loadStructPtr(fieldValue reflect.Value, prefix, path string, seen map[reflect.Type]bool, report *loadReport) error {
+	target := fieldValue
+	if fieldValue.IsNil() {
+		target = reflect.New(fieldValue.Type().Elem())
+	}
+
+	if err := s.loadStruct(target.Interface(), prefix, path, seen, report); err != nil {
+		return err
+	}
+
+	if fieldValue.IsNil() && !target.Elem().IsZero() {
+		fieldValue.Set(target)
+	}
+	return nil
+}
+
 // getEnvKey determines the environment variable key for a field.
 func (s *EnvSource) getEnvKey(field reflect.StructField, prefix string, options map[string]string) string {
 	// Check for explicit env tag
 	if envKey := options["env"]; envKey != "" {
 		// If prefix is set, prepend it to the env key
 		if s.Prefix != "" {
-			return fmt.Sprintf("%s_%s", s.Prefix, strings.ToUpper(envKey))
+			return s.joinKey(s.Prefix, strings.ToUpper(envKey))
 		}
 		return envKey
 	}
 
-	// Build key from prefix and field name
-	fieldName := field.Name
-	key := camelToSnake(fieldName)
-	upperKey := strings.ToUpper(key)
-	
-	if s.Prefix != "" {
-		return fmt.Sprintf("%s_%s", s.Prefix, upperKey)
+	// Build key from the parent's key (or the global prefix) and the field name
+	upperKey := strings.ToUpper(camelToSnake(field.Name))
+	if s.FlatNames || prefix == "" {
+		return s.joinKey(s.Prefix, upperKey)
 	}
 
-	return upperKey
+	return s.joinKey(prefix, upperKey)
+}
+
+// aliasKey returns the environment variable of an old field name: the name
+// upper-cased, with dots replaced by the separator, after the prefix.
+func (s *EnvSource) aliasKey(alias string) string {
+	segments := strings.Split(strings.ToUpper(alias), ".")
+	key := s.Prefix
+	for _, segment := range segments {
+		key = s.joinKey(key, segment)
+	}
+	return key
+}
+
+// joinKey joins two key segments with the configured separator.
+// An empty parent yields the child unchanged.
+func (s *EnvSource) joinKey(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	separator := s.Separator
+	if separator == "" {
+		separator = "_"
+	}
+	return parent + separator + child
 }
 
 // camelToSnake converts camelCase to snake_case.
@@ -141,46 +355,6 @@ func camelToSnake(s string) string {
 	return result.String()
 }
 
-// setFieldValue sets a field value from a string.
-func (s *EnvSource) setFieldValue(fieldValue reflect.Value, value string) error {
-	if !fieldValue.CanSet() {
-		return fmt.Errorf("field cannot be set")
-	}
-
-	switch fieldValue.Kind() {
-	case reflect.String:
-		fieldValue.SetString(value)
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		intVal, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
-		}
-		fieldValue.SetInt(intVal)
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		uintVal, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return fmt.Errorf("invalid unsigned integer: %w", err)
-		}
-		fieldValue.SetUint(uintVal)
-	case reflect.Float32, reflect.Float64:
-		floatVal, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return fmt.Errorf("invalid float: %w", err)
-		}
-		fieldValue.SetFloat(floatVal)
-	case reflect.Bool:
-		boolVal, err := strconv.ParseBool(value)
-		if err != nil {
-			return fmt.Errorf("invalid boolean: %w", err)
-		}
-		fieldValue.SetBool(boolVal)
-	default:
-		return fmt.Errorf("unsupported field type: %s", fieldValue.Kind())
-	}
-
-	return nil
-}
-
 // DefaultSource applies default values from struct tags.
 type DefaultSource struct{}
 
@@ -189,8 +363,16 @@ func NewDefaultSource() *DefaultSource {
 	return &DefaultSource{}
 }
 
+// Origin identifies struct tag defaults in Provenance.
+func (s *DefaultSource) Origin() Origin {
+	return Origin{Source: "default"}
+}
+
 // Load applies default values to configuration.
 func (s *DefaultSource) Load(cfg interface{}) error {
+	if err := checkConfigPointer(cfg); err != nil {
+		return err
+	}
 	return s.loadStruct(cfg)
 }
 
@@ -219,13 +401,23 @@ func (s *DefaultSource) loadStruct(cfg interface{}) error {
 		options := parseTagOptions(tag)
 
 		// Handle nested structs
-		if fieldValue.Kind() == reflect.Struct {
+		if isNestedStruct(fieldValue.Type()) {
 			if err := s.loadStruct(fieldValue.Addr().Interface()); err != nil {
 				return err
 			}
 			continue
 		}
 
+		// Pointers to nested structs receive defaults only once they are allocated
+		if fieldValue.Kind() == reflect.Ptr && isNestedStruct(fieldValue.Type().Elem()) {
+			if !fieldValue.IsNil() {
+				if err := s.loadStruct(fieldValue.Interface()); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
 		// Skip if field already has a value
 		if !isZeroValue(fieldValue) {
 			continue
@@ -233,7 +425,7 @@ func (s *DefaultSource) loadStruct(cfg interface{}) error {
 
 		// Apply default value if specified
 		if defaultValue := options["default"]; defaultValue != "" {
-			if err := s.setFieldValue(fieldValue, defaultValue); err != nil {
+			if err := setFieldValue(fieldValue, defaultValue); err != nil {
 				return fmt.Errorf("field %q: %w", field.Name, err)
 			}
 		}
@@ -242,46 +434,6 @@ func (s *DefaultSource) loadStruct(cfg interface{}) error {
 	return nil
 }
 
-// setFieldValue sets a field value from a string (same as EnvSource).
-func (s *DefaultSource) setFieldValue(fieldValue reflect.Value, value string) error {
-	if !fieldValue.CanSet() {
-		return fmt.Errorf("field cannot be set")
-	}
-
-	switch fieldValue.Kind() {
-	case reflect.String:
-		fieldValue.SetString(value)
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		intVal, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return fmt.Errorf("invalid integer: %w", err)
-		}
-		fieldValue.SetInt(intVal)
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		uintVal, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return fmt.Errorf("invalid unsigned integer: %w", err)
-		}
-		fieldValue.SetUint(uintVal)
-	case reflect.Float32, reflect.Float64:
-		floatVal, err := strconv.ParseFloat(value, 64)
-		if err != nil {
-			return fmt.Errorf("invalid float: %w", err)
-		}
-		fieldValue.SetFloat(floatVal)
-	case reflect.Bool:
-		boolVal, err := strconv.ParseBool(value)
-		if err != nil {
-			return fmt.Errorf("invalid boolean: %w", err)
-		}
-		fieldValue.SetBool(boolVal)
-	default:
-		return fmt.Errorf("unsupported field type: %s", fieldValue.Kind())
-	}
-
-	return nil
-}
-
 // isZeroValue checks if a value is the zero value for its type.
 func isZeroValue(v reflect.Value) bool {
 	switch v.Kind() {
@@ -298,7 +450,6 @@ func isZeroValue(v reflect.Value) bool {
 	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
 		return v.IsNil()
 	default:
-		return false
+		return v.IsZero()
 	}
 }
-
@@ -0,0 +1,635 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	interfaceType       = reflect.TypeOf((*interface{})(nil)).Elem()
+
+	// yamlLinePattern matches the line prefix of yaml.v3 error messages.
+	yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// DecodeError is a problem at a position in a configuration file: a syntax
+// error, a value of the wrong type or, in strict mode, a key that matches no
+// field.
+type DecodeError struct {
+	// File is the path of the configuration file. It is empty when the error
+	// is returned by a Decoder directly.
+	File string
+	// Line and Column are 1-based. Column is 0 for line-based formats such as
+	// INI, and both are 0 if the position is unknown.
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error implements the error interface, formatting the position as
+// file:line:column so that editors and CI systems can link to it.
+func (e *DecodeError) Error() string {
+	if e.File == "" {
+		switch {
+		case e.Line == 0:
+			return e.Message
+		case e.Column == 0:
+			return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+		default:
+			return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+		}
+	}
+
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	}
+}
+
+// DecodeErrors is a list of decode errors collected in a single pass, so that
+// all unknown keys of a file are reported at once.
+type DecodeErrors []*DecodeError
+
+// Error implements the error interface, reporting one error per line.
+func (e DecodeErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the individual errors, so that errors.As and errors.Is
+// can match any of them.
+func (e DecodeErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// errorOrNil returns the list as an error, or nil if it is empty.
+func (e DecodeErrors) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// withFile sets the file of the decode errors in err, unless they are
+// about an included file. Other errors are wrapped, since their position is
+// unknown.
+func withFile(path string, err error) error {
+	var errs DecodeErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if e.File == "" {
+				e.File = path
+			}
+		}
+		return errs
+	}
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		if decodeErr.File == "" {
+			decodeErr.File = path
+		}
+		return decodeErr
+	}
+	return fmt.Errorf("decode file: %w", err)
+}
+
+// unknownKeyError reports a key that matches no field.
+func unknownKeyError(line, column int, path string) *DecodeError {
+	return &DecodeError{Line: line, Column: column, Message: fmt.Sprintf("unknown key %q", path)}
+}
+
+// joinKey appends a key of the document to a dotted key path.
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// prefixKey prefixes a message with the key path it is about.
+func prefixKey(path, message string) string {
+	if path == "" {
+		return message
+	}
+	return path + ": " + message
+}
+
+// decodeYAML decodes a YAML document into v. The document is checked first
+// against the type of v, so that type errors and, if strict, unknown keys
+// are all reported with the line and column of the offending node.
+func decodeYAML(data []byte, v interface{}, strict bool) error {
+	var root yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		return yamlError(err)
+	}
+
+	if err := checkYAML(&root, v, strict, nil); err != nil {
+		return err
+	}
+
+	// With aliases, unknown keys are left to the checker, which accepts them.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(strict && len(aliasKeys(reflect.TypeOf(v))) == 0)
+	if err := decoder.Decode(v); err != nil {
+		return yamlError(err)
+	}
+	return nil
+}
+
+// checkYAML checks a YAML node tree against the type v points to. files
+// holds the nodes included from other files, whose errors are reported
+// with their file name.
+func checkYAML(root *yaml.Node, v interface{}, strict bool, files map[*yaml.Node]string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil
+	}
+	checker := &yamlChecker{strict: strict, aliases: aliasKeys(rv.Type()), files: files}
+	checker.check(root, rv.Elem().Type(), "")
+	return checker.errs.errorOrNil()
+}
+
+// yamlError converts the "line N: message" errors of yaml.v3 to decode errors.
+func yamlError(err error) error {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	var errs DecodeErrors
+	for _, message := range messages {
+		match := yamlLinePattern.FindStringSubmatch(message)
+		if match == nil {
+			return err
+		}
+		line, _ := strconv.Atoi(match[1])
+		errs = append(errs, &DecodeError{Line: line, Message: match[2]})
+	}
+	return errs.errorOrNil()
+}
+
+// yamlChecker walks a YAML node tree alongside the Go type it will be
+// decoded into, collecting type errors and, if strict, unknown keys.
+type yamlChecker struct {
+	strict bool
+	// aliases holds the old key names accepted in strict mode, from aliasKeys.
+	aliases map[string]bool
+	// files maps included nodes to their file; file is the file of the
+	// node being checked, empty for the including file.
+	files map[*yaml.Node]string
+	file  string
+	errs  DecodeErrors
+}
+
+// check checks node against type t. path is the dotted key path of node.
+func (c *yamlChecker) check(node *yaml.Node, t reflect.Type, path string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if file, ok := c.files[node]; ok {
+		defer func(parent string) { c.file = parent }(c.file)
+		c.file = file
+	}
+
+	switch {
+	case node.Kind == yaml.DocumentNode:
+		for _, child := range node.Content {
+			c.check(child, t, path)
+		}
+		return
+	case node.Kind == yaml.AliasNode:
+		// The anchored node is checked where it is defined.
+		return
+	case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+		return
+	case t.Kind() == reflect.Interface:
+		return
+	case reflect.PtrTo(t).Implements(yamlUnmarshalerType) || isTextUnmarshaler(t):
+		// Custom unmarshalers are decoded as leaves below.
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		c.checkStruct(node, t, path)
+		return
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			c.check(node.Content[i+1], t.Elem(), joinKey(path, node.Content[i].Value))
+		}
+		return
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, child := range node.Content {
+			c.check(child, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+		return
+	}
+
+	if err := node.Decode(reflect.New(t).Interface()); err != nil {
+		message := err.Error()
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+			message = typeErr.Errors[0]
+			if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+				message = match[2]
+			}
+		}
+		c.errs = append(c.errs, &DecodeError{File: c.file, Line: node.Line, Column: node.Column, Message: prefixKey(path, message)})
+	}
+}
+
+// checkStruct checks the keys and values of a mapping decoded into struct type t.
+func (c *yamlChecker) checkStruct(node *yaml.Node, t reflect.Type, path string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		// Merge keys (<<: *base) add the keys of other mappings.
+		if key.Tag == "!!merge" {
+			if value.Kind == yaml.SequenceNode {
+				for _, merged := range value.Content {
+					c.check(merged, t, path)
+				}
+			} else {
+				c.check(value, t, path)
+			}
+			continue
+		}
+
+		keyPath := joinKey(path, key.Value)
+		fieldType, ok := yamlKeyType(t, key.Value)
+		if !ok {
+			if aliasType, ok := unknownAliasKey(c.aliases, keyPath); ok {
+				c.check(value, aliasType, keyPath)
+			} else if c.strict {
+				err := unknownKeyError(key.Line, key.Column, keyPath)
+				err.File = c.file
+				c.errs = append(c.errs, err)
+			}
+			continue
+		}
+		c.check(value, fieldType, keyPath)
+	}
+}
+
+// yamlKeyType returns the type of the field of struct type t that yaml.v3
+// decodes key into: the field named by its yaml tag or, without one, the
+// field whose lower-cased name is key. Inline structs and maps are searched too.
+func yamlKeyType(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(","+opts+",", ",inline,") {
+			inline := field.Type
+			for inline.Kind() == reflect.Ptr {
+				inline = inline.Elem()
+			}
+			switch inline.Kind() {
+			case reflect.Map:
+				return inline.Elem(), true
+			case reflect.Struct:
+				if fieldType, ok := yamlKeyType(inline, key); ok {
+					return fieldType, true
+				}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if name == key {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// decodeJSON decodes the first JSON value of data into v. Like decodeYAML,
+// it checks the value against the type of v first, so that type errors and,
+// if strict, unknown keys are all reported with their position.
+func decodeJSON(data []byte, v interface{}, strict bool) error {
+	rv := reflect.ValueOf(v)
+	aliases := aliasKeys(reflect.TypeOf(v))
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		checker := &jsonChecker{data: data, decoder: json.NewDecoder(bytes.NewReader(data)), strict: strict, aliases: aliases}
+		if err := checker.check(rv.Elem().Type(), ""); err != nil {
+			return jsonError(data, err)
+		}
+		if err := checker.errs.errorOrNil(); err != nil {
+			return err
+		}
+	}
+
+	// With aliases, unknown keys are left to the checker, which accepts them.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict && len(aliases) == 0 {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return jsonError(data, err)
+	}
+	return nil
+}
+
+// jsonError converts JSON syntax and type errors, which carry a byte
+// offset, to decode errors.
+func jsonError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// The offset is just past the offending character.
+		line, column := position(data, int(syntaxErr.Offset)-1)
+		return &DecodeError{Line: line, Column: column, Message: strings.TrimPrefix(err.Error(), "json: ")}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		line, column := position(data, int(typeErr.Offset))
+		return &DecodeError{Line: line, Column: column, Message: prefixKey(typeErr.Field, strings.TrimPrefix(err.Error(), "json: "))}
+	}
+	return err
+}
+
+// position returns the 1-based line and column of the byte at offset.
+func position(data []byte, offset int) (line, column int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = offset - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// jsonChecker walks a JSON document token by token alongside the Go type it
+// will be decoded into, collecting type errors and, if strict, unknown keys.
+type jsonChecker struct {
+	data    []byte
+	decoder *json.Decoder
+	strict  bool
+	aliases map[string]bool
+	// refs resolves {"$ref": ...} objects; nil leaves them as they are.
+	refs *includer
+	errs DecodeErrors
+}
+
+// next returns the offset of the start of the next token.
+func (c *jsonChecker) next() int {
+	offset := int(c.decoder.InputOffset())
+	for offset < len(c.data) && strings.IndexByte(" \t\r\n,:", c.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// check checks the next value against type t. path is the dotted key path of
+// the value. It returns syntax errors, which end the walk.
+func (c *jsonChecker) check(t reflect.Type, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	start := c.next()
+	var first byte
+	if start < len(c.data) {
+		first = c.data[start]
+	}
+	custom := reflect.PtrTo(t).Implements(jsonUnmarshalerType) || isTextUnmarshaler(t)
+
+	switch {
+	case first == '{' && c.refs != nil && c.isRef(start):
+		return c.checkRef(t, path, start)
+	case custom || t.Kind() == reflect.Interface:
+	case first == '"' && c.refs != nil && c.refs.interpolate:
+		return c.checkInterpolated(t, path, start)
+	case first == '{' && (t.Kind() == reflect.Struct || t.Kind() == reflect.Map):
+		return c.checkObject(t, path)
+	case first == '[' && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		if _, err := c.decoder.Token(); err != nil {
+			return err
+		}
+		for i := 0; c.decoder.More(); i++ {
+			if err := c.check(t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		_, err := c.decoder.Token()
+		return err
+	}
+
+	err := c.decoder.Decode(reflect.New(t).Interface())
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &typeErr) || (custom && err != nil && !errors.As(err, &syntaxErr) && err != io.ErrUnexpectedEOF) {
+		line, column := position(c.data, start)
+		c.errs = append(c.errs, &DecodeError{Line: line, Column: column, Message: prefixKey(path, strings.TrimPrefix(err.Error(), "json: "))})
+		return nil
+	}
+	return err
+}
+
+// checkInterpolated checks the string at offset start against type t. A string
+// with variable references is only checked once expanded, when decoded.
+func (c *jsonChecker) checkInterpolated(t reflect.Type, path string, start int) error {
+	var raw json.RawMessage
+	if err := c.decoder.Decode(&raw); err != nil {
+		return err
+	}
+	if bytes.Contains(raw, []byte("${")) {
+		return nil
+	}
+	if err := json.Unmarshal(raw, reflect.New(t).Interface()); err != nil {
+		line, column := position(c.data, start)
+		c.errs = append(c.errs, &DecodeError{Line: line, Column: column, Message: prefixKey(path, strings.TrimPrefix(err.Error(), "json: "))})
+	}
+	return nil
+}
+
+// isRef reports whether the object at offset start begins with a "$ref" key.
+func (c *jsonChecker) isRef(start int) bool {
+	rest := bytes.TrimLeft(c.data[start+1:], " \t\r\n")
+	key := strconv.Quote(refKey)
+	return bytes.HasPrefix(rest, []byte(key)) && bytes.HasPrefix(bytes.TrimLeft(rest[len(key):], " \t\r\n"), []byte(":"))
+}
+
+// checkRef checks the value a {"$ref": ...} object at offset start
+// references against type t, reporting errors in the referenced file.
+func (c *jsonChecker) checkRef(t reflect.Type, path string, start int) error {
+	var object map[string]interface{}
+	if err := c.decoder.Decode(&object); err != nil {
+		return err
+	}
+	fail := func(message string) {
+		line, column := position(c.data, start)
+		c.errs = append(c.errs, &DecodeError{Line: line, Column: column, Message: prefixKey(path, message)})
+	}
+	ref, ok := refObject(object)
+	if !ok {
+		fail(refKey + " must be the only key and a string")
+		return nil
+	}
+	ref, err := c.refs.expandRef(ref, path)
+	if err != nil {
+		fail(fmt.Sprintf("%s %s: %v", refKey, ref, err))
+		return nil
+	}
+
+	frame, data, err := c.refs.open(ref)
+	if err != nil {
+		fail(fmt.Sprintf("%s %s: %v", refKey, ref, err))
+		return nil
+	}
+	defer c.refs.close()
+
+	target := &jsonChecker{data: data, decoder: json.NewDecoder(bytes.NewReader(data)), strict: c.strict, aliases: c.aliases, refs: c.refs}
+	if err := seekJSONPointer(target.decoder, frame.pointer); err != nil {
+		fail(fmt.Sprintf("%s %s: %v", refKey, ref, err))
+		return nil
+	}
+	if err := target.check(t, path); err != nil {
+		var decodeErr *DecodeError
+		if errors.As(jsonError(data, err), &decodeErr) {
+			target.errs = append(target.errs, decodeErr)
+		} else {
+			target.errs = append(target.errs, &DecodeError{Message: err.Error()})
+		}
+	}
+	for _, e := range target.errs {
+		if e.File == "" {
+			e.File = frame.name
+		}
+	}
+	c.errs = append(c.errs, target.errs...)
+	return nil
+}
+
+// checkObject checks the keys and values of an object decoded into a struct
+// or map type t.
+func (c *jsonChecker) checkObject(t reflect.Type, path string) error {
+	if _, err := c.decoder.Token(); err != nil {
+		return err
+	}
+
+	for c.decoder.More() {
+		start := c.next()
+		token, err := c.decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		keyPath := joinKey(path, key)
+
+		valueType, ok := t, true
+		if t.Kind() == reflect.Map {
+			valueType = t.Elem()
+		} else {
+			valueType, ok = jsonKeyType(t, key)
+		}
+		if !ok {
+			if aliasType, ok := unknownAliasKey(c.aliases, keyPath); ok {
+				valueType = aliasType
+			} else {
+				if c.strict {
+					line, column := position(c.data, start)
+					c.errs = append(c.errs, unknownKeyError(line, column, keyPath))
+				}
+				valueType = interfaceType
+			}
+		}
+
+		if err := c.check(valueType, keyPath); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.decoder.Token()
+	return err
+}
+
+// jsonKeyType returns the type of the field of struct type t that
+// encoding/json decodes key into: the field named by its json tag or, without
+// one, by its name, ignoring case. Fields of embedded structs are promoted.
+func jsonKeyType(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		embedded := field.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			if fieldType, ok := jsonKeyType(embedded, key); ok {
+				return fieldType, true
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// tomlError converts go-toml errors, which carry a position, to decode
+// errors. In strict mode all unknown keys are reported at once.
+func tomlError(err error) error {
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		errs := make(DecodeErrors, len(strictErr.Errors))
+		for i, missing := range strictErr.Errors {
+			line, column := missing.Position()
+			errs[i] = unknownKeyError(line, column, strings.Join(missing.Key(), "."))
+		}
+		return errs
+	}
+
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		line, column := decodeErr.Position()
+		message := strings.TrimPrefix(decodeErr.Error(), "toml: ")
+		if key := decodeErr.Key(); len(key) > 0 {
+			message = prefixKey(strings.Join(key, "."), message)
+		}
+		return &DecodeError{Line: line, Column: column, Message: message}
+	}
+	return err
+}
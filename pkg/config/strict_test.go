@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type strictConfig struct {
+	Name     string
+	Port     int
+	Timeout  time.Duration
+	Tags     []string
+	Labels   map[string]string
+	Database struct {
+		Host     string
+		MaxConns int
+	}
+	Upstreams []struct {
+		URL string
+	}
+}
+
+func TestDecodeFile_Strict(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "yaml",
+			file:    "config.yaml",
+			content: "name: app\ntimout: 30s\ndatabase:\n  hots: db.local\n  maxconns: 5\nlabels:\n  anything: goes\nupstreams:\n  - url: a\n  - urll: b\n",
+			want: []string{
+				`config.yaml:2:1: unknown key "timout"`,
+				`config.yaml:4:3: unknown key "database.hots"`,
+				`config.yaml:10:5: unknown key "upstreams[1].urll"`,
+			},
+		},
+		{
+			name:    "json",
+			file:    "config.json",
+			content: "{\n  \"name\": \"app\",\n  \"timout\": \"30s\",\n  \"database\": {\"hots\": \"db.local\", \"MaxConns\": 5},\n  \"labels\": {\"anything\": \"goes\"}\n}",
+			want: []string{
+				`config.json:3:3: unknown key "timout"`,
+				`config.json:4:16: unknown key "database.hots"`,
+			},
+		},
+		{
+			name:    "toml",
+			file:    "config.toml",
+			content: "name = \"app\"\ntimout = \"30s\"\n\n[database]\nhots = \"db.local\"\n",
+			want: []string{
+				`config.toml:2:1: unknown key "timout"`,
+				`config.toml:5:1: unknown key "database.hots"`,
+			},
+		},
+		{
+			name:    "ini",
+			file:    "config.ini",
+			content: "name = app\ntimout = 30s\n\n[database]\nhots = db.local\n",
+			want: []string{
+				`config.ini:2: unknown key "timout"`,
+				`config.ini:5: unknown key "database.hots"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			writeFiles(t, filepath.Dir(path), map[string]string{tt.file: tt.content})
+
+			// Unknown keys are ignored by default
+			var cfg strictConfig
+			if err := NewFileSource(path).Load(&cfg); err != nil {
+				t.Fatalf("Load() error = %v, want unknown keys to be ignored", err)
+			}
+			if cfg.Name != "app" {
+				t.Errorf("cfg.Name = %q, want %q", cfg.Name, "app")
+			}
+
+			source := NewFileSource(path)
+			source.Strict = true
+			err := source.Load(&strictConfig{})
+
+			var errs DecodeErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Load() error = %v, want DecodeErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Load() returned %d errors, want %d:\n%v", len(errs), len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				if got := strings.TrimPrefix(errs[i].Error(), filepath.Dir(path)+string(filepath.Separator)); got != want {
+					t.Errorf("errs[%d] = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeFile_Positions(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"yaml type error", "config.yaml", "name: app\nport: abc\n", "config.yaml:2:7: port: cannot unmarshal !!str `abc` into int"},
+		{"yaml nested type error", "config.yaml", "upstreams:\n  - url: [a]\n", "config.yaml:2:10: upstreams[0].url: cannot unmarshal !!seq into string"},
+		{"yaml syntax error", "config.yaml", "name: app\nport: 1\n  x: y\n", "config.yaml:3: mapping values are not allowed in this context"},
+		{"json type error", "config.json", "{\n  \"database\": {\n    \"maxconns\": \"many\"\n  }\n}", "config.json:3:17: database.maxconns: cannot unmarshal string into Go value of type int"},
+		{"json syntax error", "config.json", "{\n  \"name\": \"app\"\n  \"port\": 1\n}", "config.json:3:3: invalid character '\"' after object key:value pair"},
+		{"toml type error", "config.toml", "port = \"abc\"\n", "config.toml:1:8: "},
+		{"ini type error", "config.ini", "[database]\nmax_conns = many\n", `config.ini:2: key "database.max_conns"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{tt.file: tt.content})
+
+			var cfg strictConfig
+			err := DecodeFile(filepath.Join(dir, tt.file), &cfg)
+			if err == nil {
+				t.Fatal("DecodeFile() should fail")
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("DecodeFile() error = %v, want a *DecodeError", err)
+			}
+			if got := strings.TrimPrefix(err.Error(), dir+string(filepath.Separator)); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("DecodeFile() error = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFile_Strict_Tags(t *testing.T) {
+	type Base struct {
+		Region string `json:"region"`
+	}
+	type tagged struct {
+		Base
+		Name   string            `yaml:"app_name" json:"app_name"`
+		Ignore string            `yaml:"-" json:"-"`
+		Extra  map[string]string `yaml:",inline"`
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml": "base: &base\n  app_name: a\napp:\n  <<: *base\n  region: x\n",
+		"config.json": `{"APP_NAME": "a", "region": "eu", "ignore": "x"}`,
+	})
+
+	var yamlCfg struct {
+		Base map[string]string
+		App  tagged
+	}
+	source := &FileSource{Path: filepath.Join(dir, "config.yaml"), Strict: true}
+	if err := source.Load(&yamlCfg); err != nil {
+		t.Errorf("Load() error = %v, want merge keys, tags and inline maps to be known", err)
+	}
+	if yamlCfg.App.Name != "a" || yamlCfg.App.Extra["region"] != "x" {
+		t.Errorf("Load() = %+v", yamlCfg.App)
+	}
+
+	var jsonCfg tagged
+	source = &FileSource{Path: filepath.Join(dir, "config.json"), Strict: true}
+	err := source.Load(&jsonCfg)
+	if err == nil || !strings.HasSuffix(err.Error(), `:1:35: unknown key "ignore"`) {
+		t.Errorf("Load() error = %v, want only the ignored field to be unknown", err)
+	}
+}
+
+func TestLoader_Load_Strict(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.yaml": "name: app\ntimout: 30s\n"})
+	path := filepath.Join(dir, "config.yaml")
+
+	var cfg strictConfig
+	if err := NewLoaderWithConfig(Config{FilePath: path}).Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	err := NewLoaderWithConfig(Config{FilePath: path, Strict: true}).Load(&cfg)
+	if err == nil || !strings.Contains(err.Error(), path+`:2:1: unknown key "timout"`) {
+		t.Errorf("Load() error = %v, want unknown key with position", err)
+	}
+}
@@ -0,0 +1,342 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ByteSize is a number of bytes written with an optional SI (kB, MB, GB, ...)
+// or IEC (KiB, MiB, GiB, ...) unit, such as "10MB" or "512MiB". Units are
+// case-insensitive, the B may be left out ("512Mi", "10M") and plain numbers
+// are bytes. Fractions are allowed as long as they make whole bytes
+// ("1.5KiB").
+//
+// ByteSize decodes from YAML, JSON (numbers or strings), TOML, environment
+// variables and default= tags, and validate=range bounds may use units:
+//
+//	MaxBody config.ByteSize `config:"default=10MB,validate=range=1KB,1GB"`
+type ByteSize int64
+
+// Byte sizes with SI and IEC units.
+const (
+	KB ByteSize = 1000
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+	EB          = 1000 * PB
+
+	KiB ByteSize = 1024
+	MiB          = 1024 * KiB
+	GiB          = 1024 * MiB
+	TiB          = 1024 * GiB
+	PiB          = 1024 * TiB
+	EiB          = 1024 * PiB
+)
+
+// byteUnits lists the units from largest to smallest, as written by String.
+var byteUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"EiB", EiB}, {"EB", EB},
+	{"PiB", PiB}, {"PB", PB},
+	{"TiB", TiB}, {"TB", TB},
+	{"GiB", GiB}, {"GB", GB},
+	{"MiB", MiB}, {"MB", MB},
+	{"KiB", KiB}, {"kB", KB},
+}
+
+// byteUnitSizes maps lower-cased units, with and without the B, to their size.
+var byteUnitSizes = func() map[string]ByteSize {
+	sizes := map[string]ByteSize{"": 1, "b": 1}
+	for _, unit := range byteUnits {
+		name := strings.ToLower(unit.name)
+		sizes[name] = unit.size
+		sizes[strings.TrimSuffix(name, "b")] = unit.size
+	}
+	return sizes
+}()
+
+// ParseByteSize parses a byte size such as "10MB", "512MiB" or "4096".
+func ParseByteSize(s string) (ByteSize, error) {
+	number, unit := splitUnit(s)
+	size, ok := byteUnitSizes[strings.ToLower(unit)]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("invalid byte size %q: negative", s)
+		}
+		if n > math.MaxInt64/int64(size) {
+			return 0, fmt.Errorf("invalid byte size %q: too large", s)
+		}
+		return ByteSize(n) * size, nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	n := f * float64(size)
+	switch {
+	case n < 0:
+		return 0, fmt.Errorf("invalid byte size %q: negative", s)
+	case n >= math.MaxInt64:
+		return 0, fmt.Errorf("invalid byte size %q: too large", s)
+	case n != math.Trunc(n):
+		return 0, fmt.Errorf("invalid byte size %q: not a whole number of bytes", s)
+	}
+	return ByteSize(n), nil
+}
+
+// String formats the size with the largest unit that divides it exactly,
+// e.g. "10MB", "512MiB" or "1500B".
+func (b ByteSize) String() string {
+	if b != 0 {
+		for _, unit := range byteUnits {
+			if b%unit.size == 0 {
+				return strconv.FormatInt(int64(b/unit.size), 10) + unit.name
+			}
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// MarshalText formats the size as String does.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText parses a size as ParseByteSize does.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// UnmarshalJSON accepts a number of bytes or a string with a unit.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, b)
+}
+
+// Percent is a ratio written as a percentage, such as "5%" or "12.5%". The
+// value is the fraction: "5%" is 0.05. Plain numbers are fractions too, so
+// "0.05" and "5%" are the same value.
+//
+// Percent decodes from the same sources as ByteSize, and validate=range bounds
+// may be percentages:
+//
+//	Sample config.Percent `config:"default=5%,validate=range=0%,100%"`
+type Percent float64
+
+// ParsePercent parses a percentage such as "5%" or a fraction such as "0.05".
+func ParsePercent(s string) (Percent, error) {
+	value := strings.TrimSpace(s)
+	percent := strings.HasSuffix(value, "%")
+	value = strings.TrimSpace(strings.TrimSuffix(value, "%"))
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	if percent {
+		f /= 100
+	}
+	return Percent(f), nil
+}
+
+// String formats the value as a percentage, e.g. "5%".
+func (p Percent) String() string {
+	// Rounding hides binary floating-point noise, such as 0.07*100 = 7.000000000000001.
+	percentage := math.Round(float64(p)*100*1e9) / 1e9
+	return strconv.FormatFloat(percentage, 'f', -1, 64) + "%"
+}
+
+// MarshalText formats the value as String does.
+func (p Percent) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses a value as ParsePercent does.
+func (p *Percent) UnmarshalText(text []byte) error {
+	value, err := ParsePercent(string(text))
+	if err != nil {
+		return err
+	}
+	*p = value
+	return nil
+}
+
+// UnmarshalJSON accepts a fraction or a string percentage.
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, p)
+}
+
+// Duration is a time.Duration that also accepts days ("d") and weeks ("w"),
+// such as "7d" or "1d12h". A day is always 24 hours.
+//
+// Duration decodes from the same sources as ByteSize, and validate=range
+// bounds may be durations:
+//
+//	Retention config.Duration `config:"default=7d,validate=range=1h,90d"`
+type Duration time.Duration
+
+// Duration units beyond those of time.ParseDuration.
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// ParseDuration parses a duration in time.ParseDuration syntax, extended
+// with the units "d" and "w".
+func ParseDuration(s string) (Duration, error) {
+	value := strings.TrimSpace(s)
+	negative := strings.HasPrefix(value, "-")
+	if negative || strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+	if value == "0" {
+		return 0, nil
+	}
+	if value == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var total time.Duration
+	for value != "" {
+		// Split off one number and its unit
+		i := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		j := i + strings.IndexFunc(value[i:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if j < i {
+			j = len(value)
+		}
+		number, unit := value[:i], value[i:j]
+		value = value[j:]
+
+		var d time.Duration
+		switch unit {
+		case "d", "w":
+			f, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			size := day
+			if unit == "w" {
+				size = week
+			}
+			if f*float64(size) > math.MaxInt64 {
+				return 0, fmt.Errorf("invalid duration %q: too large", s)
+			}
+			d = time.Duration(f * float64(size))
+		default:
+			var err error
+			if d, err = time.ParseDuration(number + unit); err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+		}
+		if total > math.MaxInt64-d {
+			return 0, fmt.Errorf("invalid duration %q: too large", s)
+		}
+		total += d
+	}
+
+	if negative {
+		total = -total
+	}
+	return Duration(total), nil
+}
+
+// String formats the duration like time.Duration, with whole days written
+// as "d" and without zero trailing units: "7d", "1d12h", "1h30m", "90s".
+func (d Duration) String() string {
+	value := time.Duration(d)
+	sign := ""
+	if value < 0 && value > math.MinInt64 {
+		sign, value = "-", -value
+	}
+
+	var s string
+	if days := value / day; days > 0 {
+		s = strconv.FormatInt(int64(days), 10) + "d"
+		value %= day
+		if value == 0 {
+			return sign + s
+		}
+	}
+	text := value.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return sign + s + text
+}
+
+// Duration returns d as a time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// MarshalText formats the duration as String does.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a duration as ParseDuration does.
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = value
+	return nil
+}
+
+// UnmarshalJSON accepts a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, d)
+}
+
+// splitUnit splits a value such as "10MB" into its number and unit.
+func splitUnit(s string) (number, unit string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		return s, ""
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i:])
+}
+
+// unmarshalJSONText decodes a JSON string or number with UnmarshalText.
+// null leaves the value unchanged, as for built-in types.
+func unmarshalJSONText(data []byte, v interface{ UnmarshalText([]byte) error }) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		data = []byte(text)
+	}
+	return v.UnmarshalText(data)
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"4096", 4096, false},
+		{"0", 0, false},
+		{"10B", 10, false},
+		{"10kB", 10 * KB, false},
+		{"10KB", 10 * KB, false},
+		{"10MB", 10 * MB, false},
+		{"10 mb", 10 * MB, false},
+		{"10M", 10 * MB, false},
+		{"512MiB", 512 * MiB, false},
+		{"512Mi", 512 * MiB, false},
+		{"1.5KiB", 1536, false},
+		{"2GiB", 2 * GiB, false},
+		{"1TB", TB, false},
+		{"1.5B", 0, true},
+		{"-1MB", 0, true},
+		{"10XB", 0, true},
+		{"MB", 0, true},
+		{"", 0, true},
+		{"16EiB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseByteSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseByteSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	tests := map[ByteSize]string{
+		0:            "0B",
+		1500:         "1500B",
+		10 * MB:      "10MB",
+		512 * MiB:    "512MiB",
+		1536:         "1536B",
+		KiB * 1000:   "1000KiB",
+		1000 * KB:    "1MB",
+		3 * GiB:      "3GiB",
+		1234567 * KB: "1234567kB",
+	}
+	for size, want := range tests {
+		if got := size.String(); got != want {
+			t.Errorf("ByteSize(%d).String() = %q, want %q", int64(size), got, want)
+		}
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Percent
+		wantErr bool
+	}{
+		{"5%", 0.05, false},
+		{"12.5 %", 0.125, false},
+		{"150%", 1.5, false},
+		{"0.25", 0.25, false},
+		{"0", 0, false},
+		{"%", 0, true},
+		{"five%", 0, true},
+		{"NaN", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePercent(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePercent(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePercent(%q) = %v, want %v", tt.input, float64(got), float64(tt.want))
+		}
+	}
+
+	for value, want := range map[Percent]string{0.05: "5%", 0.07: "7%", 0.125: "12.5%", 1.5: "150%"} {
+		if got := value.String(); got != want {
+			t.Errorf("Percent(%v).String() = %q, want %q", float64(value), got, want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30s", 30 * time.Second, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"1d12h", 36 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"0.5d", 12 * time.Hour, false},
+		{"-1d", -24 * time.Hour, false},
+		{"+1d", 24 * time.Hour, false},
+		{"-0", 0, false},
+		{"--1d", 0, true},
+		{"+-1d", 0, true},
+		{"-+1d", 0, true},
+		{"-", 0, true},
+		{"1.5h", 90 * time.Minute, false},
+		{"0", 0, false},
+		{"30", 0, true},
+		{"d", 0, true},
+		{"7days", 0, true},
+		{"", 0, true},
+		{"200000w", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got.Duration() != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got.Duration(), tt.want)
+		}
+	}
+
+	formatted := map[Duration]string{
+		Duration(7 * 24 * time.Hour):      "7d",
+		Duration(36 * time.Hour):          "1d12h",
+		Duration(90 * time.Minute):        "1h30m",
+		Duration(time.Hour):               "1h",
+		Duration(90 * time.Second):        "1m30s",
+		Duration(1500 * time.Millisecond): "1.5s",
+		Duration(-48 * time.Hour):         "-2d",
+		0:                                 "0s",
+	}
+	for d, want := range formatted {
+		if got := d.String(); got != want {
+			t.Errorf("Duration(%v).String() = %q, want %q", time.Duration(d), got, want)
+		}
+		if parsed, err := ParseDuration(want); err != nil || parsed != d {
+			t.Errorf("ParseDuration(%q) = %v, %v, want round trip", want, parsed, err)
+		}
+	}
+}
+
+type unitsConfig struct {
+	MaxBody   ByteSize `config:"default=10MB,validate=range=1KB,1GB" yaml:"max_body" json:"max_body" toml:"max_body"`
+	Sample    Percent  `config:"default=5%,validate=range=0%,100%"`
+	Retention Duration `config:"default=7d,validate=range=1h,90d"`
+}
+
+func TestUnits_Decode(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+	}{
+		{"yaml", YAMLFormat, "max_body: 512MiB\nsample: 12.5%\nretention: 2w\n"},
+		{"yaml numbers", YAMLFormat, "max_body: 536870912\nsample: 0.125\nretention: 14d\n"},
+		{"json", JSONFormat, `{"max_body": "512MiB", "sample": "12.5%", "retention": "2w"}`},
+		{"json numbers", JSONFormat, `{"max_body": 536870912, "sample": 0.125, "retention": "336h"}`},
+		{"toml", TOMLFormat, "max_body = \"512MiB\"\nsample = \"12.5%\"\nretention = \"2w\"\n"},
+		{"ini", INIFormat, "max_body = 512MiB\nsample = 12.5%\nretention = 2w\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg unitsConfig
+			if err := decodeFormat("config", tt.format, []byte(tt.data), &cfg, true, nil, nil); err != nil {
+				t.Fatalf("decode error = %v", err)
+			}
+			if cfg.MaxBody != 512*MiB || cfg.Sample != 0.125 || cfg.Retention.Duration() != 14*24*time.Hour {
+				t.Errorf("decoded %+v", cfg)
+			}
+		})
+	}
+
+	var cfg unitsConfig
+	err := decodeFormat("config.yaml", YAMLFormat, []byte("max_body: 10XB\n"), &cfg, false, nil, nil)
+	if err == nil || err.Error() != `config.yaml:1:11: max_body: invalid byte size "10XB"` {
+		t.Errorf("yaml error = %v, want positioned byte size error", err)
+	}
+	err = decodeFormat("config.json", JSONFormat, []byte(`{"sample": "lots"}`), &cfg, false, nil, nil)
+	if err == nil || err.Error() != `config.json:1:12: sample: invalid percentage "lots"` {
+		t.Errorf("json error = %v, want positioned percentage error", err)
+	}
+}
+
+func TestUnits_Sources(t *testing.T) {
+	os.Setenv("UNITS_SAMPLE", "50%")
+	defer os.Unsetenv("UNITS_SAMPLE")
+
+	var cfg unitsConfig
+	l := NewLoaderWithConfig(Config{EnvPrefix: "UNITS", ValidateAfterLoad: true})
+	if err := l.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxBody != 10*MB || cfg.Sample != 0.5 || cfg.Retention.Duration() != 7*24*time.Hour {
+		t.Errorf("Load() = %+v", cfg)
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil || string(data) != `{"max_body":"10MB","Sample":"50%","Retention":"7d"}` {
+		t.Errorf("json.Marshal() = %s, %v", data, err)
+	}
+}
+
+func TestUnits_Range(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     unitsConfig
+		wantErr string
+	}{
+		{"valid", unitsConfig{MaxBody: 10 * MB, Sample: 0.5, Retention: Duration(time.Hour)}, ""},
+		{"bytes too small", unitsConfig{MaxBody: 512, Sample: 0.5, Retention: Duration(time.Hour)}, "value 512B is less than minimum 1kB"},
+		{"bytes too large", unitsConfig{MaxBody: 2 * GiB, Sample: 0.5, Retention: Duration(time.Hour)}, "value 2GiB is greater than maximum 1GB"},
+		{"percent too large", unitsConfig{MaxBody: MB, Sample: 1.5, Retention: Duration(time.Hour)}, "value 150% is greater than maximum 100%"},
+		{"duration too long", unitsConfig{MaxBody: MB, Retention: Duration(100 * 24 * time.Hour)}, "value 100d is greater than maximum 90d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(&tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateStruct() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateStruct() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Plain durations accept duration bounds too
+	type timeoutConfig struct {
+		Timeout time.Duration `config:"validate=range=1s,1m"`
+	}
+	err := ValidateStruct(&timeoutConfig{Timeout: 2 * time.Minute})
+	if err == nil || !strings.Contains(err.Error(), "value 2m0s is greater than maximum 1m0s") {
+		t.Errorf("ValidateStruct() error = %v, want duration range error", err)
+	}
+}
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -16,16 +18,46 @@ type Validator interface {
 
 // ValidationError represents a validation error.
 type ValidationError struct {
+	// Field is the dotted path of the field (e.g. "Server.TLS.CertFile").
 	Field   string
 	Value   interface{}
 	Message string
+	// Source names the configuration source that supplied Value
+	// (e.g. "default", "file:config.yaml", "env"). It is only set when the
+	// error is returned by Loader.Load and the value was set by a source.
+	Source string
 }
 
 // Error implements the error interface.
 func (e *ValidationError) Error() string {
+	if e.Source != "" {
+		return fmt.Sprintf("validation error for field %q: %s (value: %v, source: %s)", e.Field, e.Message, e.Value, e.Source)
+	}
 	return fmt.Sprintf("validation error for field %q: %s (value: %v)", e.Field, e.Message, e.Value)
 }
 
+// ValidationErrors is a list of validation errors collected in a single pass.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface, reporting one error per line.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the individual errors, so that errors.As and errors.Is
+// can match any of them.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
 // requiredValidator validates that a value is not empty.
 type requiredValidator struct{}
 
@@ -139,33 +171,55 @@ func NewRangeValidator(min, max *float64) Validator {
 }
 
 // Validate checks if the numeric value is within the specified range.
+// Values of types with a String method, such as time.Duration and ByteSize,
+// are reported in that form.
 func (v *rangeValidator) Validate(value interface{}) error {
-	var num float64
-
 	rv := reflect.ValueOf(value)
-	switch rv.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		num = float64(rv.Int())
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		num = float64(rv.Uint())
-	case reflect.Float32, reflect.Float64:
-		num = rv.Float()
-	default:
+	num, ok := numericValue(rv)
+	if !ok {
 		return fmt.Errorf("range validator requires numeric value")
 	}
 
 	if v.min != nil && num < *v.min {
-		return fmt.Errorf("value %v is less than minimum %v", num, *v.min)
+		return fmt.Errorf("value %v is less than minimum %v", rangeDisplay(rv, num), rangeDisplay(rv, *v.min))
 	}
 
 	if v.max != nil && num > *v.max {
-		return fmt.Errorf("value %v is greater than maximum %v", num, *v.max)
+		return fmt.Errorf("value %v is greater than maximum %v", rangeDisplay(rv, num), rangeDisplay(rv, *v.max))
 	}
 
 	return nil
 }
 
+// rangeDisplay returns num as a value of the type of rv if that type has a
+// String method, and num itself otherwise.
+func rangeDisplay(rv reflect.Value, num float64) interface{} {
+	if _, ok := rv.Interface().(fmt.Stringer); !ok {
+		return num
+	}
+	typed := reflect.New(rv.Type()).Elem()
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		typed.SetInt(int64(num))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		typed.SetUint(uint64(num))
+	default:
+		typed.SetFloat(num)
+	}
+	return typed.Interface()
+}
+
 // ValidateStruct validates a struct using struct tags.
+// All failing fields are reported at once: the returned error is a
+// ValidationErrors whose entries carry the dotted path of each field.
+//
+// Nested structs are validated wherever they appear: as fields, behind
+// non-nil pointers, and as elements of slices, arrays and maps, with paths
+// such as "Upstreams[2].URL" and "Backends[primary].Port".
+//
+// After the tag checks of a struct (the top-level one or a nested one) pass,
+// its Validate() error method is called if it has one, so that checks
+// spanning several fields can live next to the type.
 func ValidateStruct(v interface{}) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() == reflect.Ptr {
@@ -176,63 +230,298 @@ func ValidateStruct(v interface{}) error {
 		return fmt.Errorf("ValidateStruct requires a struct or pointer to struct")
 	}
 
-	return validateStructFields(rv)
+	return validateStruct(rv, nil)
+}
+
+// structValidator walks a struct and collects validation errors.
+type structValidator struct {
+	// rules holds loader-specific rules; nil when validating without a loader.
+	rules *ruleRegistry
+	// root is the top-level struct, used to resolve absolute ("$.") field paths.
+	root reflect.Value
+	errs ValidationErrors
+}
+
+// validateHook is implemented by configuration structs with their own
+// cross-field checks.
+type validateHook interface {
+	Validate() error
 }
 
-// validateStructFields iterates through struct fields and validates them.
-func validateStructFields(rv reflect.Value) error {
+// validateStruct validates a struct value using the given loader-specific
+// rules in addition to the global and built-in ones.
+func validateStruct(rv reflect.Value, rules *ruleRegistry) error {
+	// Work on an addressable copy so that Validate methods with pointer
+	// receivers can be called.
+	if !rv.CanAddr() {
+		addressable := reflect.New(rv.Type()).Elem()
+		addressable.Set(rv)
+		rv = addressable
+	}
+
+	v := &structValidator{rules: rules, root: rv}
+	v.validateStructFields(rv, "")
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// validateStructFields iterates through struct fields and validates them,
+// then runs the struct's Validate hook if its fields are valid.
+// path is the dotted path of the struct, or empty at the top level.
+func (v *structValidator) validateStructFields(rv reflect.Value, path string) {
+	errCount := len(v.errs)
+
 	rt := rv.Type()
 	for i := 0; i < rv.NumField(); i++ {
 		field := rt.Field(i)
 		fieldValue := rv.Field(i)
 
-		if err := validateField(field, fieldValue); err != nil {
-			return err
+		v.validateField(field, fieldValue, joinPath(path, field.Name), rv)
+	}
+
+	// The hook may rely on the tag checks, so it only runs when they passed.
+	if len(v.errs) == errCount {
+		v.runHook(rv, path)
+	}
+}
+
+// runHook calls the Validate method of a struct, if it has one.
+// Errors of type *ValidationError or ValidationErrors are kept, with their
+// Field prefixed by the struct's path; other errors are reported against the
+// struct itself (its path, or its type name at the top level).
+func (v *structValidator) runHook(rv reflect.Value, path string) {
+	if !rv.CanAddr() || !rv.Addr().CanInterface() {
+		return
+	}
+	hook, ok := rv.Addr().Interface().(validateHook)
+	if !ok {
+		return
+	}
+
+	err := hook.Validate()
+	if err == nil {
+		return
+	}
+
+	var errs ValidationErrors
+	var single *ValidationError
+	switch {
+	case errors.As(err, &errs):
+		for _, e := range errs {
+			e.Field = joinPath(path, e.Field)
+			v.errs = append(v.errs, e)
+		}
+	case errors.As(err, &single):
+		single.Field = joinPath(path, single.Field)
+		v.errs = append(v.errs, single)
+	default:
+		field := path
+		if field == "" {
+			field = rv.Type().Name()
 		}
+		v.errs = append(v.errs, &ValidationError{Field: field, Message: err.Error()})
 	}
-	return nil
 }
 
-// validateField validates a single struct field.
-func validateField(field reflect.StructField, fieldValue reflect.Value) error {
+// validateField validates a single struct field. parent is the struct that
+// contains the field, used to resolve sibling paths in conditional options.
+func (v *structValidator) validateField(field reflect.StructField, fieldValue reflect.Value, path string, parent reflect.Value) {
 	// Skip unexported fields
 	if !fieldValue.CanInterface() {
-		return nil
+		return
 	}
 
+	// Never expose sensitive values in error messages, including those of
+	// the fields below a sensitive struct
+	if isSensitiveField(field) {
+		defer v.maskValues(len(v.errs), path)
+	}
+
+	// Recursively validate nested structs, including those behind pointers
+	// and in slices and maps
+	v.validateNested(fieldValue, path)
+
 	tag := field.Tag.Get("config")
 	if tag == "" {
-		// Recursively validate nested structs
-		return validateNestedStruct(field, fieldValue)
+		return
 	}
 
 	options := parseTagOptions(tag)
 
-	// Validate required field
-	if err := validateRequired(field, fieldValue, options); err != nil {
-		return err
+	// Validate required field; other rules are pointless on a missing value
+	if err := validateRequired(path, fieldValue, options); err != nil {
+		v.errs = append(v.errs, err)
+		return
+	}
+
+	// Validate conditions on other fields (required_if, excluded_with, ...)
+	if err := v.validateConditions(path, fieldValue, parent, options); err != nil {
+		v.errs = append(v.errs, err)
+		return
 	}
 
 	// Validate custom rules
-	if err := validateRules(field, fieldValue, options); err != nil {
-		return err
+	if err := v.validateRules(path, fieldValue, options); err != nil {
+		v.errs = append(v.errs, err)
 	}
 
-	return nil
+	// Validate the elements against the rules following "dive"
+	if _, elementRules, dive := splitDive(splitRules(options["validate"])); dive {
+		v.validateElements(path, fieldValue, elementRules)
+	}
 }
 
-// validateNestedStruct recursively validates nested structs.
-func validateNestedStruct(field reflect.StructField, fieldValue reflect.Value) error {
-	if fieldValue.Kind() == reflect.Struct {
-		if err := ValidateStruct(fieldValue.Interface()); err != nil {
-			return fmt.Errorf("field %q: %w", field.Name, err)
+// validateNested validates the nested structs held by a field value: the
+// value itself, the target of a non-nil pointer, or the elements of a slice,
+// array or map, indexed in path as "[i]" or "[key]".
+func (v *structValidator) validateNested(rv reflect.Value, path string) {
+	if !hasNestedStruct(rv.Type()) {
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		v.validateStructFields(rv, path)
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			v.validateNested(rv.Elem(), path)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			v.validateNested(rv.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		for _, key := range sortedMapKeys(rv) {
+			// Map values are not addressable; copy them so that Validate
+			// methods with pointer receivers are called.
+			value := reflect.New(rv.Type().Elem()).Elem()
+			value.Set(rv.MapIndex(key))
+			v.validateNested(value, fmt.Sprintf("%s[%v]", path, key))
+		}
+	}
+}
+
+// hasNestedStruct reports whether values of type t can hold nested structs,
+// directly or through pointers, slices, arrays and maps.
+func hasNestedStruct(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return isNestedStruct(t)
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasNestedStruct(t.Elem())
+	default:
+		return false
+	}
+}
+
+// sortedMapKeys returns the keys of a map in the order of their formatted
+// values, so that errors are reported in a stable order.
+func sortedMapKeys(rv reflect.Value) []reflect.Value {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+// splitDive splits validation rules at the first "dive" into the rules of
+// the value and the rules of its elements. dive reports whether there was one.
+func splitDive(rules []string) (valueRules, elementRules []string, dive bool) {
+	for i, rule := range rules {
+		if rule == "dive" {
+			return rules[:i], rules[i+1:], true
+		}
+	}
+	return rules, nil, false
+}
+
+// validateElements checks each element of a slice, array or map against
+// rules, reporting every failing element. A further "dive" in rules checks
+// the elements of the elements.
+func (v *structValidator) validateElements(path string, rv reflect.Value, rules []string) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			v.validateElement(fmt.Sprintf("%s[%d]", path, i), rv.Index(i), rules)
+		}
+	case reflect.Map:
+		for _, key := range sortedMapKeys(rv) {
+			v.validateElement(fmt.Sprintf("%s[%v]", path, key), rv.MapIndex(key), rules)
+		}
+	default:
+		v.errs = append(v.errs, &ValidationError{
+			Field:   path,
+			Value:   rv.Interface(),
+			Message: fmt.Sprintf("dive requires a slice, array or map, got %s", rv.Type()),
+		})
+	}
+}
+
+// validateElement checks one element against the rules following "dive".
+// As for fields, empty elements are only checked by range rules, unless
+// "required" is one of the rules.
+func (v *structValidator) validateElement(path string, rv reflect.Value, rules []string) {
+	valueRules, elementRules, dive := splitDive(rules)
+	options := map[string]string{}
+	for _, rule := range valueRules {
+		if rule == "required" {
+			options["required"] = ""
+		}
+	}
+
+	if err := validateRequired(path, rv, options); err != nil {
+		v.errs = append(v.errs, err)
+		return
+	}
+	for _, rule := range valueRules {
+		if rule == "required" || shouldSkipValidation(rv, rule, options) {
+			continue
+		}
+		if err := validateByRuleWith(rule, rv.Interface(), v.rules); err != nil {
+			v.errs = append(v.errs, &ValidationError{
+				Field:   path,
+				Value:   rv.Interface(),
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	if dive {
+		v.validateElements(path, rv, elementRules)
+	}
+}
+
+// maskValues masks the Value of the errors reported for path, its elements
+// or its fields since index from.
+func (v *structValidator) maskValues(from int, path string) {
+	for _, err := range v.errs[from:] {
+		below := strings.HasPrefix(err.Field, path+"[") || strings.HasPrefix(err.Field, path+".")
+		if (err.Field == path || below) && err.Value != nil && !reflect.ValueOf(err.Value).IsZero() {
+			err.Value = maskedValue
 		}
 	}
-	return nil
+}
+
+// joinPath appends a field name to a dotted path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
 }
 
 // validateRequired checks if a required field is set.
-func validateRequired(field reflect.StructField, fieldValue reflect.Value, options map[string]string) error {
+func validateRequired(path string, fieldValue reflect.Value, options map[string]string) *ValidationError {
 	if _, isRequired := options["required"]; !isRequired {
 		return nil
 	}
@@ -240,7 +529,7 @@ func validateRequired(field reflect.StructField, fieldValue reflect.Value, optio
 	validator := NewRequiredValidator()
 	if err := validator.Validate(fieldValue.Interface()); err != nil {
 		return &ValidationError{
-			Field:   field.Name,
+			Field:   path,
 			Value:   fieldValue.Interface(),
 			Message: err.Error(),
 		}
@@ -249,21 +538,26 @@ func validateRequired(field reflect.StructField, fieldValue reflect.Value, optio
 }
 
 // validateRules applies custom validation rules to a field.
-func validateRules(field reflect.StructField, fieldValue reflect.Value, options map[string]string) error {
+// Rules are checked in order and the first failure is reported.
+func (v *structValidator) validateRules(path string, fieldValue reflect.Value, options map[string]string) *ValidationError {
 	validateRule := options["validate"]
 	if validateRule == "" {
 		return nil
 	}
 
-	if shouldSkipValidation(fieldValue, validateRule, options) {
-		return nil
-	}
+	// Rules following "dive" apply to the elements (see validateElements)
+	rules, _, _ := splitDive(splitRules(validateRule))
+	for _, rule := range rules {
+		if shouldSkipValidation(fieldValue, rule, options) {
+			continue
+		}
 
-	if err := validateByRule(validateRule, fieldValue.Interface()); err != nil {
-		return &ValidationError{
-			Field:   field.Name,
-			Value:   fieldValue.Interface(),
-			Message: err.Error(),
+		if err := validateByRuleWith(rule, fieldValue.Interface(), v.rules); err != nil {
+			return &ValidationError{
+				Field:   path,
+				Value:   fieldValue.Interface(),
+				Message: err.Error(),
+			}
 		}
 	}
 	return nil
@@ -283,55 +577,80 @@ func shouldSkipValidation(fieldValue reflect.Value, validateRule string, options
 	return isZeroValue(fieldValue) && !isRequired
 }
 
+// tagOptionNames lists the options understood in config tags.
+var tagOptionNames = map[string]bool{
+	"env":              true,
+	"default":          true,
+	"required":         true,
+	"validate":         true,
+	"secret":           true,
+	"desc":             true,
+	"required_if":      true,
+	"required_unless":  true,
+	"required_with":    true,
+	"required_without": true,
+	"excluded_if":      true,
+	"excluded_unless":  true,
+	"excluded_with":    true,
+	"excluded_without": true,
+	"alias":            true,
+	"deprecated":       true,
+}
+
+// listValueOptions lists the options whose values may contain commas.
+// A comma-separated segment that does not start with a known option name
+// continues the value of a preceding list option, so "validate=range=1,65535"
+// and "default=a,b" keep their commas.
+var listValueOptions = map[string]bool{
+	"default":  true,
+	"validate": true,
+}
+
 // parseTagOptions parses struct tag options.
-// Handles commas inside values (e.g., "validate=range=1,65535").
+//
+// Options are separated by commas. Commas inside values are kept when the
+// value belongs to an option listed in listValueOptions (e.g.
+// "validate=range=1,65535") or when they appear inside single quotes
+// (e.g. "default='a,b'"). Quotes are removed from option values, except for
+// validate= whose rule expression is parsed by splitRules and parseRule.
 func parseTagOptions(tag string) map[string]string {
 	options := make(map[string]string)
 	parts := splitTagIntoParts(tag)
-	mergedParts := mergeRangeValueParts(parts)
+	mergedParts := mergeContinuationParts(parts)
 
 	for _, part := range mergedParts {
 		key, value := parseKeyValuePair(part)
-		if key != "" {
-			options[key] = value
+		if key == "" {
+			continue
 		}
+		if key != "validate" {
+			value = unquote(value)
+		}
+		options[key] = value
 	}
 
 	return options
 }
 
-// splitTagIntoParts splits a tag string by commas, preserving whitespace for trimming.
+// splitTagIntoParts splits a tag string by commas outside single quotes.
+// Whitespace is kept so that values continued across commas keep their
+// spacing; parseKeyValuePair trims around keys and values.
 func splitTagIntoParts(tag string) []string {
-	parts := strings.Split(tag, ",")
-	trimmed := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed = append(trimmed, strings.TrimSpace(part))
-	}
-	return trimmed
+	return splitOutsideQuotes(tag, ',')
 }
 
-// mergeRangeValueParts merges parts that belong to range values.
-// Range values contain commas (e.g., "range=1,65535"), so we need to merge
-// parts that were incorrectly split by the comma separator.
-func mergeRangeValueParts(parts []string) []string {
-	if len(parts) == 0 {
-		return parts
-	}
-
+// mergeContinuationParts merges parts that continue the value of a list option,
+// joining them with the comma they were split on. Blank parts are dropped.
+func mergeContinuationParts(parts []string) []string {
 	merged := make([]string, 0, len(parts))
-	for i := 0; i < len(parts); i++ {
-		part := parts[i]
-		if part == "" {
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
 			continue
 		}
 
-		// Check if this part should be merged with the previous one
-		if i > 0 && len(merged) > 0 {
-			prevPart := merged[len(merged)-1]
-			if isRangeValueContinuation(prevPart, part) {
-				merged[len(merged)-1] = prevPart + "," + part
-				continue
-			}
+		if len(merged) > 0 && isContinuation(merged[len(merged)-1], part) {
+			merged[len(merged)-1] += "," + part
+			continue
 		}
 
 		merged = append(merged, part)
@@ -340,33 +659,79 @@ func mergeRangeValueParts(parts []string) []string {
 	return merged
 }
 
-// isRangeValueContinuation checks if a part should be merged with the previous part.
-// This happens when the previous part contains "range=" but doesn't have a complete
-// range value yet (missing the comma-separated min,max values).
-func isRangeValueContinuation(prevPart, currentPart string) bool {
-	// Previous part must contain "range="
-	if !strings.Contains(prevPart, "range=") {
+// isContinuation reports whether part continues the value of the previous part.
+// A part naming a known option never continues a value. A validate= value is
+// only continued while its last rule takes a parameter, such as "range=1" or
+// "regex=^a{1", so a misspelled option after a complete rule ("email") is
+// reported instead of merged into the expression.
+func isContinuation(prevPart, part string) bool {
+	key, _ := parseKeyValuePair(part)
+	if tagOptionNames[key] {
 		return false
 	}
 
-	// If previous part already has a comma, it's complete
-	if strings.Contains(prevPart, ",") && !strings.HasSuffix(prevPart, "range=") {
+	prevKey, prevValue := parseKeyValuePair(prevPart)
+	if !listValueOptions[prevKey] || !strings.Contains(prevPart, "=") {
 		return false
 	}
+	if prevKey == "validate" {
+		rules := splitRules(prevValue)
+		return strings.Contains(rules[len(rules)-1], "=")
+	}
+	return true
+}
 
-	// Current part should look like a number (range value continuation)
-	// This is a heuristic: if it starts with a digit or minus sign, it's likely a range value
-	trimmed := strings.TrimSpace(currentPart)
-	if len(trimmed) > 0 {
-		firstChar := trimmed[0]
-		if (firstChar >= '0' && firstChar <= '9') || firstChar == '-' {
-			return true
+// splitOutsideQuotes splits s on sep, ignoring separators inside single quotes.
+// A quote only opens at the start of s or of a value (after "=" or a
+// separator) and only closes before a separator or the end of s, so an
+// apostrophe inside a value such as "^it's$" does not start a quoted section.
+// Quotes are kept in the returned parts.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'' && !inQuotes && opensQuote(s, i):
+			inQuotes = true
+		case s[i] == '\'' && inQuotes && closesQuote(s, i):
+			inQuotes = false
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
 		}
 	}
+	return append(parts, s[start:])
+}
 
+// opensQuote reports whether the quote at s[i] starts a quoted value.
+func opensQuote(s string, i int) bool {
+	prev := strings.TrimRight(s[:i], " ")
+	if prev == "" {
+		return true
+	}
+	switch prev[len(prev)-1] {
+	case '=', ',', ';':
+		return true
+	}
 	return false
 }
 
+// closesQuote reports whether the quote at s[i] ends a quoted value.
+func closesQuote(s string, i int) bool {
+	next := strings.TrimLeft(s[i+1:], " ")
+	return next == "" || next[0] == ',' || next[0] == ';'
+}
+
+// unquote removes the pair of single quotes used to protect separators in
+// a tag value. Quotes inside the value are kept.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
 // parseKeyValuePair parses a single key-value pair from a tag part.
 // Returns the key and value. If no "=" is present, the entire part is the key
 // and the value is empty (for boolean flags like "required").
@@ -390,29 +755,54 @@ func parseKeyValuePair(part string) (key, value string) {
 	return key, value
 }
 
-// validateByRule validates a value using a validation rule.
+// validateByRule validates a value using a rule expression.
+// An expression is one rule ("email", "range=1,100") or several rules
+// separated by semicolons ("min_len=3;regex=^[a-z]+$"). Rules are checked
+// in order and the first failure is returned.
 func validateByRule(rule string, value interface{}) error {
-	ruleName, ruleValue := parseRule(rule)
-
-	switch ruleName {
-	case "email":
-		return validateEmail(value)
-	case "url":
-		return validateURL(value)
-	case "range":
-		return validateRange(ruleValue, value)
-	default:
-		return fmt.Errorf("unknown validation rule: %s", ruleName)
+	return validateByRuleWith(rule, value, nil)
+}
+
+// validateByRuleWith validates a value using a rule expression, looking up
+// rules in the loader-specific registry first.
+func validateByRuleWith(rule string, value interface{}, rules *ruleRegistry) error {
+	for _, r := range splitRules(rule) {
+		ruleName, ruleValue := parseRule(r)
+
+		fn, ok := lookupRule(ruleName, rules)
+		if !ok {
+			return fmt.Errorf("unknown validation rule: %s", ruleName)
+		}
+		if err := fn(value, ruleValue); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// splitRules splits a rule expression on semicolons outside single quotes.
+// Empty rules are dropped.
+func splitRules(expr string) []string {
+	var rules []string
+	for _, r := range splitOutsideQuotes(expr, ';') {
+		if r = strings.TrimSpace(r); r != "" {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return []string{""}
+	}
+	return rules
 }
 
 // parseRule parses a validation rule string into rule name and value.
+// Single quotes protecting separators in the value are removed.
 // Example: "range=1,100" -> ("range", "1,100")
 func parseRule(rule string) (ruleName, ruleValue string) {
 	parts := strings.SplitN(rule, "=", 2)
 	ruleName = strings.TrimSpace(parts[0])
 	if len(parts) > 1 {
-		ruleValue = strings.TrimSpace(parts[1])
+		ruleValue = unquote(strings.TrimSpace(parts[1]))
 	}
 	return ruleName, ruleValue
 }
@@ -434,7 +824,7 @@ func validateRange(ruleValue string, value interface{}) error {
 		return fmt.Errorf("range validator requires min and max values")
 	}
 
-	min, max, err := parseRangeValues(ruleValue)
+	min, max, err := parseTypedRangeValues(ruleValue, reflect.TypeOf(value))
 	if err != nil {
 		return err
 	}
@@ -448,11 +838,19 @@ func validateRange(ruleValue string, value interface{}) error {
 
 // parseRangeValues parses range values from a string like "1,100" or "1," or ",100".
 func parseRangeValues(ruleValue string) (min, max *float64, err error) {
+	return parseTypedRangeValues(ruleValue, nil)
+}
+
+// parseTypedRangeValues parses range values like parseRangeValues. Bounds
+// that are not plain numbers are converted to t, the type of the validated
+// value, as defaults are, so that "range=1KB,1GB" bounds a ByteSize and
+// "range=1s,1h" a time.Duration.
+func parseTypedRangeValues(ruleValue string, t reflect.Type) (min, max *float64, err error) {
 	rangeParts := strings.Split(ruleValue, ",")
 
 	// Parse minimum value
 	if len(rangeParts) > 0 && rangeParts[0] != "" {
-		val, parseErr := strconv.ParseFloat(strings.TrimSpace(rangeParts[0]), 64)
+		val, parseErr := parseRangeBound(strings.TrimSpace(rangeParts[0]), t)
 		if parseErr != nil {
 			return nil, nil, fmt.Errorf("invalid min value in range: %w", parseErr)
 		}
@@ -461,7 +859,7 @@ func parseRangeValues(ruleValue string) (min, max *float64, err error) {
 
 	// Parse maximum value
 	if len(rangeParts) > 1 && rangeParts[1] != "" {
-		val, parseErr := strconv.ParseFloat(strings.TrimSpace(rangeParts[1]), 64)
+		val, parseErr := parseRangeBound(strings.TrimSpace(rangeParts[1]), t)
 		if parseErr != nil {
 			return nil, nil, fmt.Errorf("invalid max value in range: %w", parseErr)
 		}
@@ -470,3 +868,34 @@ func parseRangeValues(ruleValue string) (min, max *float64, err error) {
 
 	return min, max, nil
 }
+
+// parseRangeBound parses a range bound as a number or, failing that, as a
+// value of numeric type t.
+func parseRangeBound(bound string, t reflect.Type) (float64, error) {
+	val, err := strconv.ParseFloat(bound, 64)
+	if err == nil || t == nil {
+		return val, err
+	}
+
+	rv := reflect.New(t).Elem()
+	if setErr := setFieldValue(rv, bound); setErr != nil {
+		return 0, err
+	}
+	if num, ok := numericValue(rv); ok {
+		return num, nil
+	}
+	return 0, err
+}
+
+// numericValue returns the value of an integer or floating-point rv.
+func numericValue(rv reflect.Value) (float64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
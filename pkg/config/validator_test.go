@@ -1,7 +1,10 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -432,7 +435,31 @@ func TestParseTagOptions(t *testing.T) {
 			name: "range with whitespace",
 			tag:  "validate=range=1, 65535",
 			expected: map[string]string{
-				"validate": "range=1,65535", // Whitespace is trimmed
+				"validate": "range=1, 65535", // Spacing inside values is kept
+			},
+		},
+		{
+			name: "default with comma and space",
+			tag:  "default=hello, world,env=GREETING",
+			expected: map[string]string{
+				"default": "hello, world",
+				"env":     "GREETING",
+			},
+		},
+		{
+			name: "misspelled option after complete rule",
+			tag:  "validate=email,omitempy",
+			expected: map[string]string{
+				"validate": "email",
+				"omitempy": "",
+			},
+		},
+		{
+			name: "apostrophe in value",
+			tag:  "validate=regex=^it's$,env=PHRASE",
+			expected: map[string]string{
+				"validate": "regex=^it's$",
+				"env":      "PHRASE",
 			},
 		},
 		{
@@ -473,6 +500,30 @@ func TestParseTagOptions(t *testing.T) {
 				"validate": "range=0.0,1.0",
 			},
 		},
+		{
+			name: "combined rules with commas",
+			tag:  "required,validate=min_len=3;regex=^[a-z]{1,8}$,env=NAME",
+			expected: map[string]string{
+				"required": "",
+				"validate": "min_len=3;regex=^[a-z]{1,8}$",
+				"env":      "NAME",
+			},
+		},
+		{
+			name: "quoted default",
+			tag:  "default='a,required',env=LIST",
+			expected: map[string]string{
+				"default": "a,required",
+				"env":     "LIST",
+			},
+		},
+		{
+			name: "quotes kept in validate",
+			tag:  "validate=regex='^[,;]$'",
+			expected: map[string]string{
+				"validate": "regex='^[,;]$'",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -509,7 +560,7 @@ func TestSplitTagIntoParts(t *testing.T) {
 		{
 			name:     "with whitespace",
 			tag:      "env=PORT , default=8080 , required",
-			expected: []string{"env=PORT", "default=8080", "required"}, // Whitespace is trimmed
+			expected: []string{"env=PORT ", " default=8080 ", " required"}, // Whitespace is kept
 		},
 		{
 			name:     "range with comma",
@@ -528,7 +579,7 @@ func TestSplitTagIntoParts(t *testing.T) {
 	}
 }
 
-func TestMergeRangeValueParts(t *testing.T) {
+func TestMergeContinuationParts(t *testing.T) {
 	tests := []struct {
 		name     string
 		parts    []string
@@ -540,7 +591,7 @@ func TestMergeRangeValueParts(t *testing.T) {
 			expected: []string{},
 		},
 		{
-			name:     "no range values",
+			name:     "no continuation",
 			parts:    []string{"env=PORT", "default=8080"},
 			expected: []string{"env=PORT", "default=8080"},
 		},
@@ -569,6 +620,31 @@ func TestMergeRangeValueParts(t *testing.T) {
 			parts:    []string{"validate=range=0.0", "1.0"},
 			expected: []string{"validate=range=0.0,1.0"},
 		},
+		{
+			name:     "spacing kept",
+			parts:    []string{"default=hello", " world"},
+			expected: []string{"default=hello, world"},
+		},
+		{
+			name:     "complete rule is not continued",
+			parts:    []string{"validate=email", "omitempy"},
+			expected: []string{"validate=email", "omitempy"},
+		},
+		{
+			name:     "regex quantifier",
+			parts:    []string{"validate=regex=^a{1", "3}$"},
+			expected: []string{"validate=regex=^a{1,3}$"},
+		},
+		{
+			name:     "default list",
+			parts:    []string{"default=a", "b", "env=HOSTS"},
+			expected: []string{"default=a,b", "env=HOSTS"},
+		},
+		{
+			name:     "non-list option is not continued",
+			parts:    []string{"env=PORT", "8080"},
+			expected: []string{"env=PORT", "8080"},
+		},
 		{
 			name:     "empty parts filtered",
 			parts:    []string{"env=PORT", "", "default=8080"},
@@ -578,75 +654,85 @@ func TestMergeRangeValueParts(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := mergeRangeValueParts(tt.parts)
+			result := mergeContinuationParts(tt.parts)
 			if !reflect.DeepEqual(result, tt.expected) {
-				t.Errorf("mergeRangeValueParts() = %v, want %v", result, tt.expected)
+				t.Errorf("mergeContinuationParts() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestIsRangeValueContinuation(t *testing.T) {
+func TestSplitRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected []string
+	}{
+		{"single rule", "email", []string{"email"}},
+		{"combined rules", "min_len=3;regex=^[a-z]+$", []string{"min_len=3", "regex=^[a-z]+$"}},
+		{"whitespace", " min_len=3 ; max_len=5 ", []string{"min_len=3", "max_len=5"}},
+		{"quoted separator", "regex='^[;a-z]+$';min_len=1", []string{"regex='^[;a-z]+$'", "min_len=1"}},
+		{"apostrophe", "regex=^it's$;max_len=5", []string{"regex=^it's$", "max_len=5"}},
+		{"range already complete", "range=1,65535", []string{"range=1,65535"}},
+		{"range with negative number", "range=-100,100;port", []string{"range=-100,100", "port"}},
+		{"range with decimal", "range=0.0,1.0", []string{"range=0.0,1.0"}},
+		{"empty", "", []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitRules(tt.expr)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("splitRules() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsContinuation(t *testing.T) {
 	tests := []struct {
 		name     string
 		prevPart string
-		currPart string
+		part     string
 		expected bool
 	}{
-		{
-			name:     "range continuation with number",
-			prevPart: "validate=range=1",
-			currPart: "65535",
-			expected: true,
-		},
-		{
-			name:     "range continuation with negative number",
-			prevPart: "validate=range=-100",
-			currPart: "100",
-			expected: true,
-		},
-		{
-			name:     "range already complete",
-			prevPart: "validate=range=1,65535",
-			currPart: "something",
-			expected: false,
-		},
-		{
-			name:     "not a range value",
-			prevPart: "env=PORT",
-			currPart: "8080",
-			expected: false,
-		},
-		{
-			name:     "range= but not continuation",
-			prevPart: "validate=range=",
-			currPart: "not-a-number",
-			expected: false,
-		},
-		{
-			name:     "range= with whitespace",
-			prevPart: "validate=range=1",
-			currPart: " 65535",
-			expected: true,
-		},
-		{
-			name:     "range= with decimal",
-			prevPart: "validate=range=0.0",
-			currPart: "1.0",
-			expected: true,
-		},
+		{"range continuation with number", "validate=range=1", "65535", true},
+		{"range continuation with negative number", "validate=range=-100", "100", true},
+		{"range continuation with whitespace", "validate=range=1", " 65535", true},
+		{"range continuation with decimal", "validate=range=0.0", "1.0", true},
+		{"not a list option", "env=PORT", "8080", false},
+		{"known option", "validate=range=1", "required", false},
+		{"rule without parameter", "validate=email", "omitempy", false},
+		{"default continuation", "default=a", "b", true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isRangeValueContinuation(tt.prevPart, tt.currPart)
-			if result != tt.expected {
-				t.Errorf("isRangeValueContinuation() = %v, want %v", result, tt.expected)
+			if result := isContinuation(tt.prevPart, tt.part); result != tt.expected {
+				t.Errorf("isContinuation(%q, %q) = %v, want %v", tt.prevPart, tt.part, result, tt.expected)
 			}
 		})
 	}
 }
 
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{"'a,b'", "a,b"},
+		{"^it's$", "^it's$"},
+		{"'it's'", "it's"},
+		{"'", "'"},
+		{"plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		if result := unquote(tt.in); result != tt.expected {
+			t.Errorf("unquote(%q) = %q, want %q", tt.in, result, tt.expected)
+		}
+	}
+}
+
 func TestParseKeyValuePair(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -745,6 +831,12 @@ func TestParseRule(t *testing.T) {
 			expectedName: "",
 			expectedVal:  "",
 		},
+		{
+			name:         "quoted value",
+			rule:         "regex='^[;]$'",
+			expectedName: "regex",
+			expectedVal:  "^[;]$",
+		},
 	}
 
 	for _, tt := range tests {
@@ -911,6 +1003,24 @@ func TestValidateByRule(t *testing.T) {
 			value:   "test",
 			wantErr: true,
 		},
+		{
+			name:    "combined rules pass",
+			rule:    "min_len=3;regex=^[a-z]+$",
+			value:   "abc",
+			wantErr: false,
+		},
+		{
+			name:    "combined rules fail on second",
+			rule:    "min_len=3;regex=^[a-z]+$",
+			value:   "abC",
+			wantErr: true,
+		},
+		{
+			name:    "combined rules with unknown rule",
+			rule:    "min_len=1;unknown",
+			value:   "abc",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1021,6 +1131,195 @@ func TestValidationError(t *testing.T) {
 	}
 }
 
+func TestValidationError_WithSource(t *testing.T) {
+	err := &ValidationError{
+		Field:   "Server.Port",
+		Value:   0,
+		Message: "required field is zero",
+		Source:  "env",
+	}
+
+	expected := `validation error for field "Server.Port": required field is zero (value: 0, source: env)`
+	if err.Error() != expected {
+		t.Errorf("ValidationError.Error() = %q, want %q", err.Error(), expected)
+	}
+}
+
+func TestValidateStruct_TagParsing(t *testing.T) {
+	type PhraseConfig struct {
+		Phrase string `config:"validate=regex=^it's$"`
+	}
+	if err := ValidateStruct(PhraseConfig{Phrase: "it's"}); err != nil {
+		t.Errorf("ValidateStruct() error = %v", err)
+	}
+
+	// Unknown options are ignored
+	type TypoConfig struct {
+		Email string `config:"validate=email,omitempy"`
+	}
+	if err := ValidateStruct(TypoConfig{Email: "ops@example.com"}); err != nil {
+		t.Errorf("ValidateStruct() error = %v, want unknown option ignored", err)
+	}
+}
+
+func TestValidateStruct_CollectsAllErrors(t *testing.T) {
+	type TLSConfig struct {
+		CertFile string `config:"required"`
+	}
+	type ServerConfig struct {
+		Port int `config:"validate=range=1,65535"`
+		TLS  TLSConfig
+	}
+	type AppConfig struct {
+		Name   string `config:"required"`
+		Email  string `config:"validate=email"`
+		Server ServerConfig
+	}
+
+	cfg := AppConfig{
+		Email:  "not-an-email",
+		Server: ServerConfig{Port: 70000},
+	}
+
+	err := ValidateStruct(cfg)
+	if err == nil {
+		t.Fatal("ValidateStruct() should return error")
+	}
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ValidateStruct() error type = %T, want ValidationErrors", err)
+	}
+
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	expected := []string{"Name", "Email", "Server.Port", "Server.TLS.CertFile"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("failing fields = %v, want %v", fields, expected)
+	}
+
+	if got := len(errs.Unwrap()); got != len(expected) {
+		t.Errorf("len(Unwrap()) = %d, want %d", got, len(expected))
+	}
+
+	var single *ValidationError
+	if !errors.As(err, &single) || single.Field != "Name" {
+		t.Errorf("errors.As(*ValidationError) = %v, want first error for field Name", single)
+	}
+
+	if lines := strings.Count(err.Error(), "\n") + 1; lines != len(expected) {
+		t.Errorf("Error() has %d lines, want %d", lines, len(expected))
+	}
+}
+
+func TestValidateStruct_Collections(t *testing.T) {
+	type Upstream struct {
+		URL    string `config:"required,validate=url"`
+		Weight int    `config:"validate=range=0,100"`
+	}
+	type Backend struct {
+		Port int `config:"validate=port"`
+	}
+	type TLSConfig struct {
+		CertFile string `config:"required"`
+	}
+	type AppConfig struct {
+		Upstreams []Upstream
+		Fallbacks []*Upstream
+		Backends  map[string]Backend
+		TLS       *TLSConfig
+		Admin     *TLSConfig
+		Hosts     []string          `config:"validate=min_len=1;dive;hostport"`
+		Tags      map[string]string `config:"validate=dive;required;max_len=5"`
+		Groups    [][]string        `config:"validate=dive;dive;oneof=a|b"`
+	}
+
+	cfg := AppConfig{
+		Upstreams: []Upstream{{URL: "http://a"}, {URL: "http://b"}, {URL: "not a url", Weight: 200}},
+		Fallbacks: []*Upstream{nil, {}},
+		Backends:  map[string]Backend{"primary": {Port: 70000}, "replica": {Port: 5432}},
+		TLS:       &TLSConfig{},
+		Hosts:     []string{"a:80", "b", ""},
+		Tags:      map[string]string{"team": "", "tier": "backend"},
+		Groups:    [][]string{{"a"}, {"b", "c"}},
+	}
+
+	err := ValidateStruct(&cfg)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ValidateStruct() error = %v, want ValidationErrors", err)
+	}
+
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	expected := []string{
+		"Upstreams[2].URL",
+		"Upstreams[2].Weight",
+		"Fallbacks[1].URL",
+		"Backends[primary].Port",
+		"TLS.CertFile",
+		"Hosts[1]",
+		"Tags[team]",
+		"Tags[tier]",
+		"Groups[1][1]",
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("failing fields = %v, want %v", fields, expected)
+	}
+
+	// Rules before dive still apply to the slice itself
+	cfg = AppConfig{Hosts: []string{"a:80"}, TLS: &TLSConfig{CertFile: "cert.pem"}}
+	if err := ValidateStruct(&cfg); err != nil {
+		t.Errorf("ValidateStruct() error = %v", err)
+	}
+	type limitedConfig struct {
+		Hosts []string `config:"required,validate=max_len=1;dive;hostport"`
+	}
+	err = ValidateStruct(limitedConfig{Hosts: []string{"a:80", "b:80"}})
+	if err == nil || !strings.Contains(err.Error(), `field "Hosts": length 2 is greater than maximum 1`) {
+		t.Errorf("ValidateStruct() error = %v, want Hosts length error", err)
+	}
+}
+
+func TestValidateStruct_DiveRequiresCollection(t *testing.T) {
+	type AppConfig struct {
+		Name string `config:"validate=dive;url"`
+	}
+	err := ValidateStruct(AppConfig{Name: "x"})
+	if err == nil || !strings.Contains(err.Error(), "dive requires a slice, array or map, got string") {
+		t.Errorf("ValidateStruct() error = %v, want dive error", err)
+	}
+}
+
+func TestValidateStruct_ElementHooks(t *testing.T) {
+	cfg := struct {
+		Ranges map[string]hookRange
+	}{
+		Ranges: map[string]hookRange{"ok": {Min: 1, Max: 2}, "bad": {Min: 3, Max: 2}},
+	}
+	err := ValidateStruct(cfg)
+	var single *ValidationError
+	if !errors.As(err, &single) || single.Field != "Ranges[bad]" {
+		t.Errorf("ValidateStruct() error = %v, want hook error for Ranges[bad]", err)
+	}
+}
+
+// hookRange has a Validate method with a pointer receiver.
+type hookRange struct {
+	Min, Max int
+}
+
+func (r *hookRange) Validate() error {
+	if r.Min > r.Max {
+		return fmt.Errorf("min %d is greater than max %d", r.Min, r.Max)
+	}
+	return nil
+}
+
 // Helper functions
 
 func floatPtr(f float64) *float64 {
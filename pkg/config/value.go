@@ -0,0 +1,273 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Value holds the current configuration of type T and lets any number of
+// goroutines read it while it is replaced on reload. The zero Value holds
+// the zero T and is ready to use.
+//
+// Stored configurations are shared between readers and must not be modified
+// after Store; store a new value instead.
+//
+// Example:
+//
+//	current := config.NewValue(cfg)
+//	current.SubscribeChanges(func(old, new AppConfig, changes config.Changes) {
+//		if changes.Has("Logger.Level") {
+//			log.SetLevel(new.Logger.Level)
+//		}
+//	})
+//
+//	// In a handler
+//	timeout := current.Load().HTTP.Timeout
+type Value[T any] struct {
+	current atomic.Pointer[T]
+
+	// notifyMu serializes Store, so that subscribers see the configurations
+	// in the order they were stored.
+	notifyMu sync.Mutex
+
+	mu          sync.Mutex
+	subscribers map[int]func(old, new T, changes Changes)
+	nextID      int
+}
+
+// NewValue creates a Value holding cfg.
+func NewValue[T any](cfg T) *Value[T] {
+	v := &Value[T]{}
+	v.current.Store(&cfg)
+	return v
+}
+
+// Load returns the current configuration.
+func (v *Value[T]) Load() T {
+	if cfg := v.current.Load(); cfg != nil {
+		return *cfg
+	}
+	var zero T
+	return zero
+}
+
+// Store replaces the current configuration. If any field changed,
+// subscribers are called with the previous and the new configuration, in
+// the goroutine calling Store. Concurrent calls to Store are serialized, so
+// each subscriber sees every change once and in order; subscribers must
+// not call Store themselves.
+func (v *Value[T]) Store(cfg T) {
+	v.notifyMu.Lock()
+	defer v.notifyMu.Unlock()
+
+	var old T
+	if previous := v.current.Swap(&cfg); previous != nil {
+		old = *previous
+	}
+
+	changes := Diff(old, cfg)
+	if len(changes) == 0 {
+		return
+	}
+
+	v.mu.Lock()
+	ids := make([]int, 0, len(v.subscribers))
+	for id := range v.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	subscribers := make([]func(old, new T, changes Changes), len(ids))
+	for i, id := range ids {
+		subscribers[i] = v.subscribers[id]
+	}
+	v.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(old, cfg, changes)
+	}
+}
+
+// Subscribe registers fn to be called by Store with the previous and the
+// new configuration when the configuration changes, in the order
+// subscribers were added. The returned function removes the subscription.
+func (v *Value[T]) Subscribe(fn func(old, new T)) (unsubscribe func()) {
+	return v.SubscribeChanges(func(old, new T, _ Changes) {
+		fn(old, new)
+	})
+}
+
+// SubscribeChanges is like Subscribe, but fn also receives the changed
+// fields, as returned by Diff.
+func (v *Value[T]) SubscribeChanges(fn func(old, new T, changes Changes)) (unsubscribe func()) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.subscribers == nil {
+		v.subscribers = make(map[int]func(old, new T, changes Changes))
+	}
+	id := v.nextID
+	v.nextID++
+	v.subscribers[id] = fn
+
+	return func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		delete(v.subscribers, id)
+	}
+}
+
+// WatchValue watches the loader's sources like Watcher.Watch and stores each
+// successfully reloaded configuration in v, notifying its subscribers. It
+// blocks until ctx is done and then returns ctx.Err(). The loader must
+// implement Watcher, as the loaders returned by NewLoader do.
+//
+// Example:
+//
+//	current := config.NewValue(cfg)
+//	go config.WatchValue(ctx, loader, current)
+func WatchValue[T any](ctx context.Context, l Loader, v *Value[T]) error {
+	watcher, ok := l.(Watcher)
+	if !ok {
+		return fmt.Errorf("WatchValue: %T does not implement Watcher", l)
+	}
+	cfg := v.Load()
+	return watcher.Watch(ctx, &cfg, func(fresh interface{}) {
+		v.Store(*fresh.(*T))
+	})
+}
+
+// Change is a leaf field whose value differs between two configurations.
+type Change struct {
+	// Path is the dotted path of the field (e.g. "Logger.Level").
+	Path string
+	// Old and New are the field values. A field below a nil pointer to a
+	// struct is reported with a nil value.
+	Old interface{}
+	New interface{}
+
+	sensitive bool
+}
+
+// String returns "Path: old -> new", with the values of sensitive fields
+// masked, including those of structs held by slices, arrays and maps.
+func (c Change) String() string {
+	if c.sensitive {
+		return fmt.Sprintf("%s: %s -> %s", c.Path, maskedValue, maskedValue)
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Path, formatChangeValue(c.Old), formatChangeValue(c.New))
+}
+
+// formatChangeValue formats a value of a Change, masking the sensitive
+// fields of the structs it holds.
+func formatChangeValue(value interface{}) string {
+	if value == nil {
+		return "<nil>"
+	}
+	rv := reflect.ValueOf(value)
+	if hasNestedStruct(rv.Type()) {
+		rv = copyValue(rv)
+		redactNested(rv)
+	}
+	return formatValue(rv)
+}
+
+// Changes lists changed fields, sorted by path.
+type Changes []Change
+
+// Has reports whether the field at path, or any field below it, changed:
+// Has("Database") is true if Database.Host changed.
+func (c Changes) Has(path string) bool {
+	for _, change := range c {
+		if change.Path == path || strings.HasPrefix(change.Path, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns one change per line, with sensitive values masked.
+func (c Changes) String() string {
+	lines := make([]string, len(c))
+	for i, change := range c {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff compares two configurations of the same struct type field by field
+// and returns the leaf fields that differ. Slices and maps are compared as a
+// whole. Pointers to structs are followed, so a nil pointer differs from an
+// allocated one in each of its fields.
+func Diff(old, new interface{}) Changes {
+	before := diffLeaves(old)
+	after := diffLeaves(new)
+
+	var changes Changes
+	for path, a := range after {
+		b, ok := before[path]
+		if ok && reflect.DeepEqual(a.value, b.value) {
+			continue
+		}
+		changes = append(changes, Change{Path: path, Old: b.value, New: a.value, sensitive: a.sensitive})
+	}
+	for path, b := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, Change{Path: path, Old: b.value, sensitive: b.sensitive})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// diffLeaf is the value of a leaf field compared by Diff.
+type diffLeaf struct {
+	value     interface{}
+	sensitive bool
+}
+
+// diffLeaves returns the leaf fields of a struct or pointer to struct, keyed by path.
+func diffLeaves(cfg interface{}) map[string]diffLeaf {
+	leaves := make(map[string]diffLeaf)
+	rv := reflect.ValueOf(cfg)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		collectDiffLeaves(rv, "", false, leaves)
+	}
+	return leaves
+}
+
+// collectDiffLeaves walks a struct value, adding its leaves to leaves.
+// sensitive marks every leaf, for structs below a sensitive field.
+func collectDiffLeaves(rv reflect.Value, path string, sensitive bool, leaves map[string]diffLeaf) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+		fieldSensitive := sensitive || isSensitiveField(field)
+		switch {
+		case isNestedStruct(field.Type):
+			collectDiffLeaves(fieldValue, fieldPath, fieldSensitive, leaves)
+		case field.Type.Kind() == reflect.Ptr && isNestedStruct(field.Type.Elem()):
+			if !fieldValue.IsNil() {
+				collectDiffLeaves(fieldValue.Elem(), fieldPath, fieldSensitive, leaves)
+			}
+		default:
+			leaves[fieldPath] = diffLeaf{value: fieldValue.Interface(), sensitive: fieldSensitive}
+		}
+	}
+}
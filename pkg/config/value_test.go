@@ -0,0 +1,254 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type valueConfig struct {
+	Logger struct {
+		Level string
+	}
+	HTTP struct {
+		Timeout time.Duration
+	}
+	Hosts    []string
+	Token    string
+	Database *struct {
+		Host string
+	}
+}
+
+func TestValue(t *testing.T) {
+	var zero Value[valueConfig]
+	if got := zero.Load(); got.Logger.Level != "" {
+		t.Errorf("zero Value Load() = %+v, want zero config", got)
+	}
+
+	initial := valueConfig{Hosts: []string{"a"}}
+	initial.Logger.Level = "info"
+	v := NewValue(initial)
+
+	type notification struct {
+		old, new valueConfig
+		changes  Changes
+	}
+	var got []notification
+	unsubscribe := v.SubscribeChanges(func(old, new valueConfig, changes Changes) {
+		got = append(got, notification{old, new, changes})
+	})
+	var order []int
+	v.Subscribe(func(valueConfig, valueConfig) { order = append(order, 1) })
+	v.SubscribeChanges(func(valueConfig, valueConfig, Changes) { order = append(order, 2) })
+
+	next := v.Load()
+	next.Logger.Level = "debug"
+	next.HTTP.Timeout = time.Second
+	v.Store(next)
+
+	if v.Load().Logger.Level != "debug" {
+		t.Errorf("Load() = %+v, want stored config", v.Load())
+	}
+	if len(got) != 1 {
+		t.Fatalf("subscriber called %d times, want 1", len(got))
+	}
+	if got[0].old.Logger.Level != "info" || got[0].new.Logger.Level != "debug" {
+		t.Errorf("subscriber got old=%+v new=%+v", got[0].old, got[0].new)
+	}
+	if paths := changePaths(got[0].changes); !reflect.DeepEqual(paths, []string{"HTTP.Timeout", "Logger.Level"}) {
+		t.Errorf("changes = %v, want HTTP.Timeout and Logger.Level", paths)
+	}
+	if !reflect.DeepEqual(order, []int{1, 2}) {
+		t.Errorf("subscribers called in order %v, want [1 2]", order)
+	}
+
+	// Storing an equal config notifies nobody
+	v.Store(v.Load())
+	if len(got) != 1 {
+		t.Errorf("subscriber called for an unchanged config")
+	}
+
+	unsubscribe()
+	next.Token = "new"
+	v.Store(next)
+	if len(got) != 1 {
+		t.Errorf("subscriber called after unsubscribe")
+	}
+}
+
+func TestValue_Concurrent(t *testing.T) {
+	v := NewValue(valueConfig{})
+
+	// Stores are notified one at a time, so each notification starts from
+	// the configuration of the previous one.
+	var last valueConfig
+	var outOfOrder int
+	v.Subscribe(func(old, new valueConfig) {
+		if !reflect.DeepEqual(old, last) {
+			outOfOrder++
+		}
+		last = new
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			cfg := valueConfig{Hosts: []string{strings.Repeat("h", i)}}
+			for j := 0; j < 100; j++ {
+				cfg.HTTP.Timeout = time.Duration(j)
+				v.Store(cfg)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = v.Load().HTTP.Timeout
+			}
+		}()
+	}
+	wg.Wait()
+
+	if outOfOrder != 0 {
+		t.Errorf("%d notifications did not start from the previous configuration", outOfOrder)
+	}
+	if !reflect.DeepEqual(last, v.Load()) {
+		t.Errorf("last notification = %+v, want the current configuration %+v", last, v.Load())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var old, new valueConfig
+	old.Hosts = []string{"a"}
+	new.Hosts = []string{"a", "b"}
+	new.Token = "s3cret"
+	new.Database = &struct{ Host string }{Host: "db"}
+
+	changes := Diff(old, &new)
+	if paths := changePaths(changes); !reflect.DeepEqual(paths, []string{"Database.Host", "Hosts", "Token"}) {
+		t.Fatalf("Diff() = %v", paths)
+	}
+	if changes[0].Old != nil || changes[0].New != "db" {
+		t.Errorf("changes[0] = %+v, want nil -> db", changes[0])
+	}
+
+	if !changes.Has("Database") || !changes.Has("Database.Host") || changes.Has("Data") || changes.Has("Logger") {
+		t.Error("Has() should match changed fields and the structs containing them")
+	}
+
+	want := "Database.Host: <nil> -> db\nHosts: [a] -> [a b]\nToken: ****** -> ******"
+	if got := changes.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if changes := Diff(new, new); len(changes) != 0 {
+		t.Errorf("Diff() of equal configs = %v, want none", changes)
+	}
+}
+
+func TestDiff_Collections(t *testing.T) {
+	type Upstream struct {
+		Host     string
+		Password string
+	}
+	type CollectionConfig struct {
+		Upstreams []Upstream
+		Backends  map[string]Upstream
+	}
+
+	old := CollectionConfig{
+		Upstreams: []Upstream{{Host: "a.local", Password: "hunter2"}},
+		Backends:  map[string]Upstream{"primary": {Host: "b.local", Password: "hunter2"}},
+	}
+	new := CollectionConfig{
+		Upstreams: []Upstream{{Host: "a.local", Password: "s3cret"}},
+		Backends:  map[string]Upstream{"primary": {Host: "c.local", Password: "s3cret"}},
+	}
+
+	changes := Diff(old, new)
+	want := "Backends: map[primary:{b.local ******}] -> map[primary:{c.local ******}]\n" +
+		"Upstreams: [{a.local ******}] -> [{a.local ******}]"
+	if got := changes.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// The values themselves are left intact
+	if changes[1].New.([]Upstream)[0].Password != "s3cret" || new.Backends["primary"].Password != "s3cret" {
+		t.Error("String() should not modify the values of the changes")
+	}
+}
+
+func TestDiff_SensitiveStruct(t *testing.T) {
+	type Credentials struct {
+		User string
+		Pass string
+	}
+	type ParentConfig struct {
+		DB      Credentials  `config:"secret"`
+		Replica *Credentials `config:"secret"`
+		Region  string
+	}
+
+	old := ParentConfig{DB: Credentials{User: "app", Pass: "hunter2"}, Region: "eu"}
+	new := ParentConfig{
+		DB:      Credentials{User: "app", Pass: "newpass"},
+		Replica: &Credentials{User: "replica"},
+		Region:  "us",
+	}
+
+	want := "DB.Pass: ****** -> ******\nRegion: eu -> us\nReplica.Pass: ****** -> ******\nReplica.User: ****** -> ******"
+	if got := Diff(old, new).String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestWatchValue(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filePath, []byte("logger:\n  level: info\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	loader := NewLoader(WithFile(filePath), WithWatchInterval(10*time.Millisecond))
+	cfg, err := Load[valueConfig](WithFile(filePath))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	v := NewValue(cfg)
+	changed := make(chan Changes, 1)
+	v.SubscribeChanges(func(old, new valueConfig, changes Changes) { changed <- changes })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchValue(ctx, loader, v)
+	// Let Watch take its baseline fingerprint before editing the file.
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filePath, []byte("logger:\n  level: debug\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+	select {
+	case changes := <-changed:
+		if !changes.Has("Logger.Level") || v.Load().Logger.Level != "debug" {
+			t.Errorf("changes = %v, Load() = %+v", changes, v.Load())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+}
+
+// changePaths returns the paths of changes.
+func changePaths(changes Changes) []string {
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.Path
+	}
+	return paths
+}
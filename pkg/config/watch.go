@@ -0,0 +1,127 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// defaultWatchInterval is the polling interval used when Config.WatchInterval is not set.
+const defaultWatchInterval = 2 * time.Second
+
+// Watch polls the configuration files (FilePath, FilePaths, or the FileSources
+// given with WithSources), DirSources and RemoteSources for changes until ctx
+// is cancelled.
+// Directories and glob patterns are re-expanded on every poll, so adding or
+// removing a file (including a profile overlay) counts as a change.
+// Whenever the content changes, the load pipeline is
+// re-run into a fresh value of the same type as cfg and validated. onChange
+// receives a pointer to the fresh value only if loading and validation succeed,
+// so a bad edit never replaces a good configuration. Failed reloads are reported
+// to Config.OnWatchError. cfg itself is never modified.
+// Watch blocks until ctx is done and then returns ctx.Err().
+func (l *loader) Watch(ctx context.Context, cfg interface{}, onChange func(cfg interface{})) error {
+	if err := checkConfigPointer(cfg); err != nil {
+		return err
+	}
+	if onChange == nil {
+		return fmt.Errorf("onChange callback is required")
+	}
+	last, err := l.sourcesFingerprint()
+	if errors.Is(err, errNothingToWatch) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	interval := l.config.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := l.sourcesFingerprint()
+		if err != nil {
+			// The file may be briefly missing while an editor replaces it.
+			l.reportWatchError(fmt.Errorf("watch: %w", err))
+			continue
+		}
+		if current == last {
+			continue
+		}
+		last = current
+
+		fresh := reflect.New(reflect.TypeOf(cfg).Elem()).Interface()
+		if err := l.reload(fresh); err != nil {
+			l.reportWatchError(fmt.Errorf("reload: %w", err))
+			continue
+		}
+		onChange(fresh)
+	}
+}
+
+// reload loads cfg through the full pipeline and always validates it,
+// regardless of ValidateAfterLoad.
+func (l *loader) reload(cfg interface{}) error {
+	if err := l.Load(cfg); err != nil {
+		return err
+	}
+	if !l.config.ValidateAfterLoad {
+		if err := l.validate(cfg); err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// reportWatchError forwards a watch error to the configured handler, if any.
+func (l *loader) reportWatchError(err error) {
+	if l.config.OnWatchError != nil {
+		l.config.OnWatchError(err)
+	}
+}
+
+// errNothingToWatch is returned by Watch when no source can be polled.
+var errNothingToWatch = errors.New("watch requires a file path or a file source")
+
+// sourcesFingerprint returns a hash of the input of every pollable source
+// (the names and content of the configuration files), used to detect
+// changes. Content is hashed rather than relying on modification times,
+// whose granularity can hide quick successive writes.
+func (l *loader) sourcesFingerprint() ([sha256.Size]byte, error) {
+	stages, err := l.stages()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+
+	hash := sha256.New()
+	watched := 0
+	for _, stage := range stages {
+		if stage.fingerprint == nil {
+			continue
+		}
+		if err := stage.fingerprint(hash); err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		watched++
+	}
+	if watched == 0 {
+		return [sha256.Size]byte{}, errNothingToWatch
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
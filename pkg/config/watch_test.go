@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoader_Watch(t *testing.T) {
+	type WatchedConfig struct {
+		Level string `config:"required"`
+		Limit int    `config:"validate=range=1,100"`
+	}
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(filePath, []byte("level: info\nlimit: 10\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	changes := make(chan *WatchedConfig, 4)
+	watchErrs := make(chan error, 4)
+	loader := NewLoaderWithConfig(Config{
+		FilePath:          filePath,
+		ValidateAfterLoad: true,
+		WatchInterval:     10 * time.Millisecond,
+		OnWatchError:      func(err error) { watchErrs <- err },
+	})
+
+	var cfg WatchedConfig
+	if err := loader.Load(&cfg); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- loader.(Watcher).Watch(ctx, &cfg, func(c interface{}) {
+			changes <- c.(*WatchedConfig)
+		})
+	}()
+	// Let Watch take its baseline fingerprint before editing the file.
+	time.Sleep(50 * time.Millisecond)
+
+	// A valid edit is delivered to the callback.
+	if err := os.WriteFile(filePath, []byte("level: debug\nlimit: 20\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+	select {
+	case got := <-changes:
+		if got.Level != "debug" || got.Limit != 20 {
+			t.Errorf("reloaded config = %+v, want Level=debug Limit=20", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+
+	// An invalid edit is reported and never reaches the callback.
+	if err := os.WriteFile(filePath, []byte("level: debug\nlimit: 500\n"), 0644); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+	select {
+	case err := <-watchErrs:
+		if err == nil {
+			t.Error("OnWatchError received nil error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload error")
+	}
+	select {
+	case got := <-changes:
+		t.Errorf("callback invoked with invalid config %+v", got)
+	default:
+	}
+
+	// The original value is left untouched.
+	if cfg.Level != "info" || cfg.Limit != 10 {
+		t.Errorf("cfg = %+v, want original values", cfg)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Watch() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+}
+
+func TestLoader_Watch_InvalidArguments(t *testing.T) {
+	type WatchedConfig struct {
+		Level string
+	}
+
+	noop := func(interface{}) {}
+	ctx := context.Background()
+
+	var cfg WatchedConfig
+	if err := NewLoader().(Watcher).Watch(ctx, &cfg, noop); err == nil {
+		t.Error("Watch() should return error without a file path")
+	}
+
+	withFile := NewLoaderWithConfig(Config{FilePath: "/nonexistent/config.yaml"}).(Watcher)
+	if err := withFile.Watch(ctx, cfg, noop); err == nil {
+		t.Error("Watch() should return error for non-pointer config")
+	}
+	if err := withFile.Watch(ctx, &cfg, nil); err == nil {
+		t.Error("Watch() should return error for nil callback")
+	}
+	if err := withFile.Watch(ctx, &cfg, noop); err == nil {
+		t.Error("Watch() should return error for missing file")
+	}
+}